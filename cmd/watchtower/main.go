@@ -1,6 +1,7 @@
 package main
 
 import (
+    "context"
     "errors"
     "flag"
     "fmt"
@@ -9,14 +10,20 @@ import (
     "os"
     "os/signal"
     "syscall"
+    "time"
 
     "github.com/iplay88keys/watchtower/pkg/config"
+    "github.com/iplay88keys/watchtower/pkg/console"
 )
 
 var configFile string
+var matchPath string
+
+const defaultShutdownTimeout = 30 * time.Second
 
 func main() {
     flag.StringVar(&configFile, "config-file", "", "a string var")
+    flag.StringVar(&matchPath, "matches", "", "list the watches a change to this path would trigger, then exit")
 
     flag.Parse()
 
@@ -29,54 +36,181 @@ func main() {
         panic(err)
     }
 
-    stop, quit, err := setupWatchers(cfg)
-    defer stop()
+    if flag.Arg(0) == "explain" {
+        err = explain(cfg, flag.Args()[1:])
+        if err != nil {
+            panic(err)
+        }
+
+        return
+    }
+
+    if matchPath != "" {
+        err = listMatches(cfg, matchPath)
+        if err != nil {
+            panic(err)
+        }
+
+        return
+    }
+
+    pathWatcher, err := addWatches(cfg)
     if err != nil {
         panic(err)
     }
 
-    c := make(chan os.Signal, 1)
-    signal.Notify(c, os.Interrupt)
+    stop, quit := pathWatcher.Watch()
 
     sigs := make(chan os.Signal, 1)
-    done := make(chan bool, 1)
-
     signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 
+    // The first interrupt stops the watch and cancels startup, so that
+    // Watchtower doesn't keep starting processes or waiting for them to be
+    // ready once it has been asked to exit.
+    ctx, cancel := context.WithCancel(context.Background())
     go func() {
-        for {
-            select {
-            case sig := <-sigs:
-                fmt.Println()
-                fmt.Println(sig)
-                done <- true
-            case <-quit:
-                done <- true
-            }
+        select {
+        case sig := <-sigs:
+            fmt.Println()
+            fmt.Println(sig)
+            stop()
+        case <-ctx.Done():
         }
+
+        cancel()
     }()
 
-    <-done
+    err = start(ctx, cfg, pathWatcher)
+    if err != nil && ctx.Err() == nil {
+        cancel()
+        stop()
+
+        teardown(cfg, sigs, quit)
+        panic(err)
+    }
+
+    select {
+    case <-ctx.Done():
+    case <-quit:
+    }
+
+    cancel()
+
     fmt.Println("Exiting")
+    stop()
+
+    err = teardown(cfg, sigs, quit)
+    if err != nil {
+        fmt.Println(err)
+        os.Exit(1)
+    }
+}
+
+// teardown waits for the watcher to quit, so that no trigger can start a
+// process after it has been stopped, then stops the processes and runs their
+// cleanup commands. Whatever is still running when the shutdown timeout
+// passes, or when another interrupt arrives, is killed straight away.
+func teardown(cfg *config.Config, sigs <-chan os.Signal, quit <-chan struct{}) error {
+    timeout := defaultShutdownTimeout
+    if cfg.ShutdownTimeout > 0 {
+        timeout = time.Duration(cfg.ShutdownTimeout)
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), timeout)
+    defer cancel()
+
+    go func() {
+        select {
+        case <-sigs:
+            fmt.Println()
+            fmt.Println("Interrupted again, killing every process")
+            cancel()
+        case <-ctx.Done():
+        }
+    }()
+
+    select {
+    case <-quit:
+    case <-ctx.Done():
+        fmt.Println("Stopping the processes before the running triggers have finished")
+    }
+
+    return runners.StopProcesses(ctx, processes(cfg))
 }
 
-func setupWatchers(cfg *config.Config) (func(), chan struct{}, error) {
+// start runs the startup triggers and starts the processes, stopping early
+// once the context is done.
+func start(ctx context.Context, cfg *config.Config, pathWatcher *watchers.PathWatcher) error {
+    err := runOnStart(ctx, cfg, pathWatcher, config.BeforeProcesses)
+    if err != nil {
+        return err
+    }
+
+    fmt.Println("Running startup processes:")
+    err = runners.StartProcesses(ctx, processes(cfg))
+    if err != nil {
+        return err
+    }
+
+    err = runOnStart(ctx, cfg, pathWatcher, config.AfterProcesses)
+    if err != nil {
+        return err
+    }
+
+    fmt.Println("Type 'help' for console commands")
+    go console.Run(os.Stdin, pathWatcher, processList(processes(cfg)))
+
+    return nil
+}
+
+// processList reports on the processes for the console.
+type processList []*runners.Process
+
+func (l processList) Processes() []runners.ProcessStatus {
+    var statuses []runners.ProcessStatus
+    for _, process := range l {
+        statuses = append(statuses, process.Status())
+    }
+
+    return statuses
+}
+
+// processes returns the configured processes, shared with the restart
+// triggers so that both see the same running state.
+func processes(cfg *config.Config) []*runners.Process {
+    var procs []*runners.Process
+    for i := range cfg.Processes {
+        procs = append(procs, &cfg.Processes[i])
+    }
+
+    return procs
+}
+
+func addWatches(cfg *config.Config) (*watchers.PathWatcher, error) {
     pathWatcher, err := watchers.NewPathWatcher()
     if err != nil {
-        return nil, nil, err
+        return nil, err
     }
 
+    pathWatcher.SetMaxConcurrentTriggers(cfg.MaxConcurrentTriggers)
+
     for _, watch := range cfg.Watches {
         var triggers []*runners.Config
         for _, trigger := range watch.OnTrigger {
             restartRunnerConfig, ok := trigger.Config.(*runners.Restart)
             if ok {
-                for _, process := range cfg.Processes {
+                for _, process := range processes(cfg) {
                     if process.Name == restartRunnerConfig.Restart {
-                        store := process
-                        restartRunnerConfig.Setup(&store)
+                        restartRunnerConfig.Setup(process)
                     }
                 }
+
+                var dependents []runners.Dependent
+                for _, dependent := range runners.Dependents(processes(cfg), restartRunnerConfig.Restart) {
+                    dependents = append(dependents, dependent)
+                }
+
+                restartRunnerConfig.SetupDependents(dependents...)
             }
 
             trig := trigger
@@ -85,22 +219,182 @@ func setupWatchers(cfg *config.Config) (func(), chan struct{}, error) {
 
         pathWatcherConfig, ok := watch.Config.Config.(*watchers.Path)
         if ok {
-            err = pathWatcher.Add(*pathWatcherConfig, triggers, watch.Name)
+            err = pathWatcher.Add(*pathWatcherConfig, triggers, watch.Name, watch.Options)
             if err != nil {
-                return nil, nil, err
+                return nil, err
             }
         }
     }
 
-    fmt.Println("Running startup processes:")
-    for _, process := range cfg.Processes {
-        err := process.Start()
+    for _, process := range processes(cfg) {
+        err = pathWatcher.IgnoreOutputs(process.Name, process.Outputs, process)
         if err != nil {
-            return nil, nil, err
+            return nil, err
         }
     }
 
-    stop, quit := pathWatcher.Watch()
+    for _, process := range processes(cfg) {
+        if !process.RestartOnEnvFileChange || len(process.EnvFile) == 0 {
+            continue
+        }
+
+        restart := &runners.Restart{Restart: process.Name}
+        restart.Setup(process)
+
+        triggers := []*runners.Config{{Config: restart}}
+
+        err = pathWatcher.Add(envFileWatch(process), triggers, envFileWatchName(process), watchers.Options{})
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    return pathWatcher, nil
+}
+
+// envFileWatch watches the env files of a process that restarts when they
+// change.
+func envFileWatch(process *runners.Process) watchers.Path {
+    return watchers.Path{
+        Paths:  process.EnvFile,
+        Events: []string{"create", "write"},
+    }
+}
+
+func envFileWatchName(process *runners.Process) string {
+    return fmt.Sprintf("%s env files", process.Name)
+}
+
+func listMatches(cfg *config.Config, path string) error {
+    explainer, err := newExplainer(cfg)
+    if err != nil {
+        return err
+    }
+
+    names, err := explainer.Matches(path)
+    if err != nil {
+        return err
+    }
+
+    fmt.Printf("Watches matching '%s':\n", path)
+    for _, name := range names {
+        fmt.Println(name)
+    }
+
+    return nil
+}
+
+// explain prints which watches a change to a path would trigger, with the
+// triggers they would run in order, and why each of the others wouldn't.
+func explain(cfg *config.Config, args []string) error {
+    flags := flag.NewFlagSet("explain", flag.ExitOnError)
+    op := flags.String("op", "write", "the event to explain for the path")
+
+    // The path can come before or after the flags.
+    err := flags.Parse(args)
+    if err != nil {
+        return err
+    }
+
+    if flags.NArg() == 0 {
+        return errors.New("usage: explain <path> [-op create|write|remove|rename|chmod]")
+    }
+
+    path := flags.Arg(0)
+    err = flags.Parse(flags.Args()[1:])
+    if err != nil {
+        return err
+    }
+
+    explainer, err := newExplainer(cfg)
+    if err != nil {
+        return err
+    }
+
+    explanations, err := explainer.Explain(path, *op)
+    if err != nil {
+        return err
+    }
+
+    fmt.Printf("Explaining a %s of '%s':\n", *op, path)
+    for _, explanation := range explanations {
+        if !explanation.Matched {
+            fmt.Printf("'%s' doesn't match: %s\n", explanation.Name, explanation.Reason)
+            continue
+        }
+
+        fmt.Printf("'%s' matches and runs:\n", explanation.Name)
+        for i, trigger := range explanation.Triggers {
+            fmt.Printf("  %d. %s\n", i+1, trigger)
+        }
+    }
+
+    return nil
+}
+
+// newExplainer adds the configured watches to an explainer, which evaluates
+// them against a path without watching anything.
+func newExplainer(cfg *config.Config) (*watchers.Explainer, error) {
+    explainer := watchers.NewExplainer()
+    for _, watch := range cfg.Watches {
+        var triggers []*runners.Config
+        for i := range watch.OnTrigger {
+            triggers = append(triggers, &watch.OnTrigger[i])
+        }
+
+        pathWatcherConfig, ok := watch.Config.Config.(*watchers.Path)
+        if ok {
+            err := explainer.Add(*pathWatcherConfig, triggers, watch.Name, watch.Options)
+            if err != nil {
+                return nil, err
+            }
+        }
+    }
+
+    for _, process := range processes(cfg) {
+        if !process.RestartOnEnvFileChange || len(process.EnvFile) == 0 {
+            continue
+        }
+
+        triggers := []*runners.Config{{Config: &runners.Restart{Restart: process.Name}}}
+
+        err := explainer.Add(envFileWatch(process), triggers, envFileWatchName(process), watchers.Options{})
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    return explainer, nil
+}
+
+// runOnStart runs the triggers of each watch that should run on start in the
+// given order relative to the processes. A failed run is only reported, as it
+// is when a change triggers it, so that a broken first build leaves the watch
+// waiting for the change that fixes it. It only returns an error once the
+// context is done.
+func runOnStart(ctx context.Context, cfg *config.Config, pathWatcher *watchers.PathWatcher, order string) error {
+    ran := make(map[string]bool)
+    for _, watch := range cfg.Watches {
+        if ctx.Err() != nil {
+            return ctx.Err()
+        }
+
+        watchOrder := watch.RunOnStartOrder
+        if watchOrder == "" {
+            watchOrder = config.AfterProcesses
+        }
+
+        if !watch.RunOnStart || watchOrder != order || ran[watch.Name] {
+            continue
+        }
+
+        ran[watch.Name] = true
+
+        err := pathWatcher.RunTriggers(watch.Name)
+        if err != nil {
+            fmt.Printf("Error running '%s': %s\n", watch.Name, err)
+        }
+    }
 
-    return stop, quit, nil
+    return nil
 }
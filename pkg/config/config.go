@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 
 	"sigs.k8s.io/yaml"
@@ -10,15 +11,36 @@ import (
 	"github.com/iplay88keys/watchtower/pkg/watchers"
 )
 
+const (
+	BeforeProcesses = "beforeProcesses"
+	AfterProcesses  = "afterProcesses"
+)
+
 type Config struct {
 	Watches   []Watch           `json:"watches"`
 	Processes []runners.Process `json:"processes"`
+
+	// MaxConcurrentTriggers limits how many watches can run their triggers
+	// at the same time. Zero means no limit.
+	MaxConcurrentTriggers int `json:"maxConcurrentTriggers"`
+
+	// ShutdownTimeout limits how long stopping and cleaning up the
+	// processes can take when Watchtower exits. Defaults to 30 seconds.
+	ShutdownTimeout runners.Duration `json:"shutdownTimeout"`
 }
 
 type Watch struct {
 	Name      string           `json:"name"`
 	Config    watchers.Config  `json:"config"`
 	OnTrigger []runners.Config `json:"onTrigger"`
+
+	watchers.Options
+
+	// RunOnStart runs the triggers once at startup, before any path has
+	// changed. RunOnStartOrder decides whether that happens before or after
+	// the processes are started, and defaults to after.
+	RunOnStart      bool   `json:"runOnStart"`
+	RunOnStartOrder string `json:"runOnStartOrder"`
 }
 
 func Load(path string) (*Config, error) {
@@ -39,5 +61,33 @@ func Load(path string) (*Config, error) {
 		return nil, err
 	}
 
+	for _, watch := range cfg.Watches {
+		switch watch.RunOnStartOrder {
+		case "", BeforeProcesses, AfterProcesses:
+		default:
+			return nil, fmt.Errorf("unknown runOnStartOrder for watch '%s': %s", watch.Name, watch.RunOnStartOrder)
+		}
+	}
+
+	for i := range cfg.Processes {
+		process := &cfg.Processes[i]
+
+		switch process.RestartPolicy {
+		case "", runners.RestartNever, runners.RestartAlways, runners.RestartOnFailure:
+		default:
+			return nil, fmt.Errorf("unknown restartPolicy for process '%s': %s", process.Name, process.RestartPolicy)
+		}
+	}
+
+	var processes []*runners.Process
+	for i := range cfg.Processes {
+		processes = append(processes, &cfg.Processes[i])
+	}
+
+	_, err = runners.SortProcesses(processes)
+	if err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
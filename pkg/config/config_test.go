@@ -2,6 +2,7 @@ package config_test
 
 import (
 	"io/ioutil"
+	"time"
 
 	"github.com/iplay88keys/watchtower/pkg/config"
 	"github.com/iplay88keys/watchtower/pkg/runners"
@@ -62,6 +63,124 @@ var _ = Describe("Config", func() {
 		}))
 	})
 
+	It("loads whether to run a watch's triggers on start", func() {
+		f, err := ioutil.TempFile("", "config.yml")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = f.WriteString(runOnStartConfig)
+		Expect(err).ToNot(HaveOccurred())
+
+		cfg, err := config.Load(f.Name())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Watches).To(HaveLen(2))
+		Expect(cfg.Watches[0].RunOnStart).To(BeTrue())
+		Expect(cfg.Watches[0].RunOnStartOrder).To(Equal(config.BeforeProcesses))
+		Expect(cfg.Watches[1].RunOnStart).To(BeTrue())
+		Expect(cfg.Watches[1].RunOnStartOrder).To(BeEmpty())
+	})
+
+	It("loads the options for when a watch's triggers run", func() {
+		f, err := ioutil.TempFile("", "config.yml")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = f.WriteString(limitsConfig)
+		Expect(err).ToNot(HaveOccurred())
+
+		cfg, err := config.Load(f.Name())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Watches[0].Options).To(Equal(watchers.Options{
+			MinInterval:     runners.Duration(5 * time.Minute),
+			MaxRunsPerHour:  6,
+			Priority:        10,
+			StopPropagation: true,
+			MutexGroups:     []string{"build", "db"},
+			Concurrency:     watchers.ConcurrencyRestart,
+		}))
+		Expect(cfg.MaxConcurrentTriggers).To(Equal(2))
+	})
+
+	It("returns an error if runOnStartOrder is unknown", func() {
+		f, err := ioutil.TempFile("", "config.yml")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = f.WriteString(unknownRunOnStartOrderConfig)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = config.Load(f.Name())
+		Expect(err).To(MatchError("unknown runOnStartOrder for watch 'build': sometime"))
+	})
+
+	It("loads the restart policy for a process", func() {
+		f, err := ioutil.TempFile("", "config.yml")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = f.WriteString(restartPolicyConfig)
+		Expect(err).ToNot(HaveOccurred())
+
+		cfg, err := config.Load(f.Name())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Processes).To(HaveLen(1))
+		Expect(cfg.Processes[0].RestartPolicy).To(Equal(runners.RestartOnFailure))
+		Expect(cfg.Processes[0].RestartBackoff).To(Equal(runners.Duration(2 * time.Second)))
+		Expect(cfg.Processes[0].MaxRestarts).To(Equal(3))
+		Expect(cfg.Processes[0].RestartWindow).To(Equal(runners.Duration(5 * time.Minute)))
+	})
+
+	It("returns an error if a restartPolicy is unknown", func() {
+		f, err := ioutil.TempFile("", "config.yml")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = f.WriteString(unknownRestartPolicyConfig)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = config.Load(f.Name())
+		Expect(err).To(MatchError("unknown restartPolicy for process 'server': sometimes"))
+	})
+
+	It("loads the environment for processes and run triggers", func() {
+		f, err := ioutil.TempFile("", "config.yml")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = f.WriteString(envConfig)
+		Expect(err).ToNot(HaveOccurred())
+
+		cfg, err := config.Load(f.Name())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Processes[0].Environment).To(Equal(runners.Environment{
+			EnvFile: []string{".env"},
+			Env:     map[string]string{"PORT": "8080"},
+		}))
+		Expect(cfg.Processes[0].RestartOnEnvFileChange).To(BeTrue())
+		Expect(cfg.Watches[0].OnTrigger[0].Config).To(Equal(&runners.Run{
+			Run: []string{"go test ./..."},
+			Environment: runners.Environment{
+				Env: map[string]string{"GOFLAGS": "-count=1"},
+			},
+		}))
+	})
+
+	It("returns an error if the process dependencies form a cycle", func() {
+		f, err := ioutil.TempFile("", "config.yml")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = f.WriteString(dependencyCycleConfig)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = config.Load(f.Name())
+		Expect(err).To(MatchError("dependency cycle between processes: backend -> db -> backend"))
+	})
+
+	It("returns an error if more than one process has the same name", func() {
+		f, err := ioutil.TempFile("", "config.yml")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = f.WriteString(duplicateProcessNameConfig)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = config.Load(f.Name())
+		Expect(err).To(MatchError("more than one process is named 'backend'"))
+	})
+
 	It("returns an error if the file doesn't exist", func() {
 		_, err := config.Load("non-existent.yml")
 		Expect(err).To(HaveOccurred())
@@ -105,4 +224,124 @@ processes:
     start: "echo 'hello'"
 `
 
+const runOnStartConfig = `
+watches:
+  - name: "build"
+    config:
+      paths:
+        - "src"
+    onTrigger:
+      - run:
+        - "make"
+    runOnStart: true
+    runOnStartOrder: "beforeProcesses"
+  - name: "test"
+    config:
+      paths:
+        - "test"
+    onTrigger:
+      - run:
+        - "make test"
+    runOnStart: true
+`
+
+const limitsConfig = `
+maxConcurrentTriggers: 2
+watches:
+  - name: "integration"
+    config:
+      paths:
+        - "src"
+    onTrigger:
+      - run:
+        - "make integration"
+    minInterval: "5m"
+    maxRunsPerHour: 6
+    priority: 10
+    stopPropagation: true
+    mutexGroups:
+      - "build"
+      - "db"
+    concurrency: "restart"
+`
+
+const restartPolicyConfig = `
+processes:
+  - name: "server"
+    type: "background"
+    start: "go run ."
+    restartPolicy: "on-failure"
+    restartBackoff: "2s"
+    maxRestarts: 3
+    restartWindow: "5m"
+`
+
+const unknownRestartPolicyConfig = `
+processes:
+  - name: "server"
+    type: "background"
+    start: "go run ."
+    restartPolicy: "sometimes"
+`
+
+const envConfig = `
+watches:
+  - name: "test"
+    config:
+      paths:
+        - "src"
+    onTrigger:
+      - run:
+        - "go test ./..."
+        env:
+          GOFLAGS: "-count=1"
+processes:
+  - name: "server"
+    type: "background"
+    start: "go run ."
+    envFile:
+      - ".env"
+    env:
+      PORT: "8080"
+    restartOnEnvFileChange: true
+`
+
+const dependencyCycleConfig = `
+processes:
+  - name: "backend"
+    type: "background"
+    start: "go run ."
+    dependsOn:
+      - name: "db"
+        condition: "ready"
+  - name: "db"
+    type: "background"
+    start: "postgres"
+    dependsOn:
+      - name: "backend"
+`
+
+const duplicateProcessNameConfig = `
+processes:
+  - name: "backend"
+    type: "background"
+    start: "go run ."
+  - name: "backend"
+    type: "task"
+    start: "go build ."
+`
+
+const unknownRunOnStartOrderConfig = `
+watches:
+  - name: "build"
+    config:
+      paths:
+        - "src"
+    onTrigger:
+      - run:
+        - "make"
+    runOnStart: true
+    runOnStartOrder: "sometime"
+`
+
 const invalidConfig = `:-`
@@ -0,0 +1,136 @@
+package console
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/iplay88keys/watchtower/pkg/runners"
+	"github.com/iplay88keys/watchtower/pkg/watchers"
+)
+
+// Controller is what the console controls while watchtower is running.
+type Controller interface {
+	Pause(name string) error
+	Mute(name string) error
+	Resume(name string) error
+	RunTriggers(name string) error
+	Status() []watchers.WatchStatus
+}
+
+// Processes reports on the processes watchtower is running.
+type Processes interface {
+	Processes() []runners.ProcessStatus
+}
+
+const help = `Commands:
+  pause <watch>   stop running the watch's triggers, holding changes until it is resumed
+  mute <watch>    stop running the watch's triggers, ignoring changes until it is resumed
+  resume <watch>  run the watch's triggers again, for any held changes first
+  run <watch>     run the watch's triggers now
+  status          list the watches and their states
+  processes       list the processes, their states and recent health checks
+  help            show this help
+`
+
+// Run reads commands from in, one per line, until it is closed.
+func Run(in io.Reader, controller Controller, processes Processes) {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		err := execute(line, controller, processes)
+		if err != nil {
+			fmt.Println("Error:", err.Error())
+		}
+	}
+}
+
+func execute(line string, controller Controller, processes Processes) error {
+	command, name := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		command, name = line[:i], strings.TrimSpace(line[i+1:])
+	}
+
+	actions := map[string]func(string) error{
+		"pause":  controller.Pause,
+		"mute":   controller.Mute,
+		"resume": controller.Resume,
+		"run":    controller.RunTriggers,
+	}
+
+	if action, ok := actions[command]; ok {
+		if name == "" {
+			return fmt.Errorf("'%s' needs the name of a watch", command)
+		}
+
+		return action(name)
+	}
+
+	switch command {
+	case "status":
+		for _, status := range controller.Status() {
+			printStatus(status)
+		}
+	case "processes":
+		for _, status := range processes.Processes() {
+			printProcess(status)
+		}
+	case "help":
+		fmt.Print(help)
+	default:
+		fmt.Printf("Unknown command '%s'\n", command)
+		fmt.Print(help)
+	}
+
+	return nil
+}
+
+func printStatus(status watchers.WatchStatus) {
+	details := status.State
+	if status.Running {
+		details += ", running"
+	}
+
+	if status.Held > 0 {
+		details += fmt.Sprintf(", %d change(s) held", status.Held)
+	}
+
+	fmt.Printf("'%s': %s\n", status.Name, details)
+}
+
+func printProcess(status runners.ProcessStatus) {
+	details := "stopped"
+	if status.Running {
+		details = "running"
+	} else if status.LastExit != nil {
+		details = "exited with " + status.LastExit.String()
+	}
+
+	if status.Failed {
+		details += ", crash looping"
+	}
+
+	if len(status.Health) > 0 {
+		if status.Health[len(status.Health)-1].Healthy {
+			details += ", healthy"
+		} else {
+			details += ", unhealthy"
+		}
+	}
+
+	fmt.Printf("'%s': %s\n", status.Name, details)
+
+	for _, result := range status.Health {
+		outcome := "healthy"
+		if !result.Healthy {
+			outcome = "unhealthy: " + result.Error
+		}
+
+		fmt.Printf("  %s %s\n", result.Time.Format("15:04:05"), outcome)
+	}
+}
@@ -0,0 +1,161 @@
+package console_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/iplay88keys/watchtower/pkg/console"
+	"github.com/iplay88keys/watchtower/pkg/runners"
+	"github.com/iplay88keys/watchtower/pkg/watchers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Console", func() {
+	var processes []runners.ProcessStatus
+
+	BeforeEach(func() {
+		processes = nil
+	})
+
+	run := func(input string, controller console.Controller) string {
+		stdout := os.Stdout
+		r, w, err := os.Pipe()
+		Expect(err).ToNot(HaveOccurred())
+		os.Stdout = w
+
+		console.Run(strings.NewReader(input), controller, &processesMock{statuses: processes})
+
+		err = w.Close()
+		Expect(err).ToNot(HaveOccurred())
+
+		out, err := ioutil.ReadAll(r)
+		Expect(err).ToNot(HaveOccurred())
+
+		os.Stdout = stdout
+
+		return string(out)
+	}
+
+	It("passes commands for a watch on to the controller", func() {
+		controller := &controllerMock{}
+
+		run("pause backend\nmute  frontend build\n\nresume backend\nrun tests\n", controller)
+
+		Expect(controller.calls).To(Equal([]string{
+			"pause backend",
+			"mute frontend build",
+			"resume backend",
+			"run tests",
+		}))
+	})
+
+	It("prints the status of every watch", func() {
+		controller := &controllerMock{
+			statuses: []watchers.WatchStatus{{
+				Name:  "backend",
+				State: watchers.StatePaused,
+				Held:  2,
+			}, {
+				Name:    "frontend",
+				State:   watchers.StateActive,
+				Running: true,
+			}},
+		}
+
+		Expect(run("status\n", controller)).To(Equal("'backend': paused, 2 change(s) held\n'frontend': active, running\n"))
+	})
+
+	It("prints the status and health history of every process", func() {
+		checked := time.Date(2021, 6, 1, 12, 0, 5, 0, time.Local)
+
+		processes = []runners.ProcessStatus{{
+			Name:    "backend",
+			Running: true,
+			Health: []runners.HealthResult{{
+				Time:    checked,
+				Healthy: true,
+			}, {
+				Time:  checked.Add(10 * time.Second),
+				Error: "couldn't connect to 'localhost:8080'",
+			}},
+		}, {
+			Name:   "api",
+			Failed: true,
+			LastExit: &runners.ExitStatus{
+				Code:    1,
+				Runtime: 20 * time.Millisecond,
+			},
+		}, {
+			Name: "docs",
+		}}
+
+		Expect(run("processes\n", &controllerMock{})).To(Equal("" +
+			"'backend': running, unhealthy\n" +
+			"  12:00:05 healthy\n" +
+			"  12:00:15 unhealthy: couldn't connect to 'localhost:8080'\n" +
+			"'api': exited with code 1 after 20ms, crash looping\n" +
+			"'docs': stopped\n"))
+	})
+
+	It("prints errors from the controller", func() {
+		controller := &controllerMock{err: errors.New("no watch named 'missing'")}
+
+		Expect(run("pause missing\n", controller)).To(Equal("Error: no watch named 'missing'\n"))
+	})
+
+	It("needs a watch name for commands on a watch", func() {
+		controller := &controllerMock{}
+
+		Expect(run("pause\n", controller)).To(Equal("Error: 'pause' needs the name of a watch\n"))
+		Expect(controller.calls).To(BeEmpty())
+	})
+
+	It("prints the help for unknown commands", func() {
+		out := run("restart backend\n", &controllerMock{})
+
+		Expect(out).To(HavePrefix("Unknown command 'restart'\nCommands:\n"))
+	})
+})
+
+type controllerMock struct {
+	calls    []string
+	statuses []watchers.WatchStatus
+	err      error
+}
+
+func (c *controllerMock) Pause(name string) error {
+	c.calls = append(c.calls, "pause "+name)
+	return c.err
+}
+
+func (c *controllerMock) Mute(name string) error {
+	c.calls = append(c.calls, "mute "+name)
+	return c.err
+}
+
+func (c *controllerMock) Resume(name string) error {
+	c.calls = append(c.calls, "resume "+name)
+	return c.err
+}
+
+func (c *controllerMock) RunTriggers(name string) error {
+	c.calls = append(c.calls, "run "+name)
+	return c.err
+}
+
+func (c *controllerMock) Status() []watchers.WatchStatus {
+	return c.statuses
+}
+
+type processesMock struct {
+	statuses []runners.ProcessStatus
+}
+
+func (p *processesMock) Processes() []runners.ProcessStatus {
+	return p.statuses
+}
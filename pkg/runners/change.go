@@ -0,0 +1,68 @@
+package runners
+
+import "strings"
+
+// Change describes the file change that caused a runner to be executed.
+// When several changes are merged into one run, Names holds every file that
+// changed while the other fields describe the latest change.
+type Change struct {
+	Name     string
+	Names    []string
+	OldName  string
+	Op       string
+	Diff     string
+	PrevPath string
+}
+
+var (
+	NameTemplate     = "{{.Name}}"
+	NamesTemplate    = "{{.Names}}"
+	OldNameTemplate  = "{{.OldName}}"
+	DiffTemplate     = "{{.Diff}}"
+	PrevPathTemplate = "{{.PrevPath}}"
+)
+
+// Expand replaces the supported templates in command with the values of the
+// change. {{.Name}} is inserted as it is, as it always has been, so commands
+// that quote it keep working. The other values can hold spaces and
+// characters the shell treats specially, so each one is inserted as a single
+// shell quoted argument, except {{.Names}} which inserts each name as a
+// separate one. Empty values are replaced with nothing.
+func (c Change) Expand(command string) string {
+	names := c.Names
+	if len(names) == 0 && c.Name != "" {
+		names = []string{c.Name}
+	}
+
+	quotedNames := make([]string, len(names))
+	for i, name := range names {
+		quotedNames[i] = shellQuote(name)
+	}
+
+	replacements := []struct {
+		template string
+		value    string
+	}{
+		{NameTemplate, c.Name},
+		{NamesTemplate, strings.Join(quotedNames, " ")},
+		{OldNameTemplate, shellQuote(c.OldName)},
+		{DiffTemplate, shellQuote(c.Diff)},
+		{PrevPathTemplate, shellQuote(c.PrevPath)},
+	}
+
+	for _, replacement := range replacements {
+		if strings.Contains(command, replacement.template) {
+			command = strings.ReplaceAll(command, replacement.template, replacement.value)
+		}
+	}
+
+	return command
+}
+
+func shellQuote(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,75 @@
+package runners_test
+
+import (
+	"github.com/iplay88keys/watchtower/pkg/runners"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Change", func() {
+	It("replaces the name templates", func() {
+		change := runners.Change{
+			Name:    "new.go",
+			OldName: "old.go",
+		}
+
+		Expect(change.Expand("mv {{.OldName}} {{.Name}} && cat {{.Name}}")).To(Equal("mv 'old.go' new.go && cat new.go"))
+	})
+
+	It("replaces the previous content path template", func() {
+		change := runners.Change{
+			Name:     "main.go",
+			PrevPath: "/tmp/prev.go",
+		}
+
+		Expect(change.Expand("diff {{.PrevPath}} {{.Name}}")).To(Equal("diff '/tmp/prev.go' main.go"))
+	})
+
+	It("quotes old names that hold spaces and shell characters", func() {
+		change := runners.Change{
+			Name:    "new.go",
+			OldName: "it's; rm -rf x.go",
+		}
+
+		Expect(change.Expand("mv {{.OldName}} {{.Name}}")).To(Equal(`mv 'it'\''s; rm -rf x.go' new.go`))
+	})
+
+	It("inserts the name as it is so that commands can quote it", func() {
+		change := runners.Change{
+			Name: "my file.go",
+		}
+
+		Expect(change.Expand(`go test "{{.Name}}"`)).To(Equal(`go test "my file.go"`))
+	})
+
+	It("replaces templates of empty values with nothing", func() {
+		Expect(runners.Change{}.Expand("ls {{.OldName}}")).To(Equal("ls "))
+	})
+
+	It("inserts the diff as a single quoted argument", func() {
+		change := runners.Change{
+			Diff: "-it's\n+it is\n",
+		}
+
+		Expect(change.Expand("echo {{.Diff}}")).To(Equal(`echo '-it'\''s` + "\n" + `+it is` + "\n'"))
+	})
+
+	It("inserts every changed name as a separate quoted argument", func() {
+		change := runners.Change{
+			Name:  "b.go",
+			Names: []string{"a.go", "my b.go"},
+		}
+
+		Expect(change.Expand("gofmt -l {{.Names}}")).To(Equal("gofmt -l 'a.go' 'my b.go'"))
+	})
+
+	It("uses the name for the names of a single change", func() {
+		Expect(runners.Change{Name: "a.go"}.Expand("gofmt -l {{.Names}}")).To(Equal("gofmt -l 'a.go'"))
+		Expect(runners.Change{}.Expand("gofmt -l {{.Names}}")).To(Equal("gofmt -l "))
+	})
+
+	It("leaves commands without templates alone", func() {
+		Expect(runners.Change{Name: "main.go"}.Expand("go build ./...")).To(Equal("go build ./..."))
+	})
+})
@@ -1,17 +1,32 @@
 package runners
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 )
 
 type RunnerConfig interface {
-	Execute(triggeringFileName string) error
+	Execute(ctx context.Context, change Change) error
 }
 
 type Config struct {
 	Config RunnerConfig
+
+	// Outputs are globs of files the runner writes. Changes to them while it
+	// runs, and for QuietPeriod after, are not treated as new changes.
+	Outputs []string
+
+	// QuietPeriod is how long after the runner finishes that changes it
+	// caused may still arrive. Without outputs every change to the watch
+	// in that window is ignored.
+	QuietPeriod Duration
+}
+
+type commonConfig struct {
+	Outputs     []string `json:"outputs"`
+	QuietPeriod Duration `json:"quietPeriod"`
 }
 
 func (c *Config) UnmarshalJSON(data []byte) error {
@@ -44,5 +59,14 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 		return errors.New(fmt.Sprint("unknown runner config:", string(data)))
 	}
 
+	var common commonConfig
+	err = json.Unmarshal(data, &common)
+	if err != nil {
+		return err
+	}
+
+	c.Outputs = common.Outputs
+	c.QuietPeriod = common.QuietPeriod
+
 	return nil
 }
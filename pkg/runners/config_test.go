@@ -2,6 +2,7 @@ package runners_test
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/iplay88keys/watchtower/pkg/runners"
 
@@ -30,6 +31,19 @@ var _ = Describe("Config", func() {
 		}}))
 	})
 
+	It("unmarshals the outputs and quiet period of any runner", func() {
+		var runnerConfig runners.Config
+		err := json.Unmarshal([]byte(`{"run": ["go generate"], "outputs": ["gen/**"], "quietPeriod": "2s"}`), &runnerConfig)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(runnerConfig).To(Equal(runners.Config{
+			Config: &runners.Run{
+				Run: []string{"go generate"},
+			},
+			Outputs:     []string{"gen/**"},
+			QuietPeriod: runners.Duration(2 * time.Second),
+		}))
+	})
+
 	It("returns an error if the config type is unknown", func() {
 		var runnerConfig runners.Config
 		err := json.Unmarshal([]byte(`{"unknown": "something"}`), &runnerConfig)
@@ -0,0 +1,233 @@
+package runners
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Dependency conditions decide when a process that depends on another one
+// can be started.
+const (
+	// DependsOnStarted waits for the dependency's start command to have
+	// started. Tasks only count as started once they have completed.
+	DependsOnStarted = "started"
+
+	// DependsOnReady waits for the dependency to pass its ready checks.
+	DependsOnReady = "ready"
+
+	// DependsOnCompleted waits for a task to complete successfully.
+	DependsOnCompleted = "completed"
+)
+
+// Dependency is a process that has to meet a condition before the process
+// that depends on it is started.
+type Dependency struct {
+	Name      string `json:"name"`
+	Condition string `json:"condition"`
+}
+
+// SortProcesses returns the processes ordered so that every process comes
+// after the processes it depends on, keeping the given order otherwise. It
+// returns an error if a process has no name or shares its name with another
+// one, if a dependency is unknown or if the dependencies form a cycle.
+func SortProcesses(processes []*Process) ([]*Process, error) {
+	byName, err := processesByName(processes)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, process := range processes {
+		for _, dep := range process.DependsOn {
+			dependency, ok := byName[dep.Name]
+			if !ok {
+				return nil, fmt.Errorf("process '%s' depends on unknown process '%s'", process.Name, dep.Name)
+			}
+
+			switch dep.Condition {
+			case "", DependsOnStarted, DependsOnReady:
+			case DependsOnCompleted:
+				if dependency.Type != "task" {
+					return nil, fmt.Errorf("process '%s' can only depend on '%s' completing if it is a task", process.Name, dep.Name)
+				}
+			default:
+				return nil, fmt.Errorf("unknown condition for process '%s' depending on '%s': %s", process.Name, dep.Name, dep.Condition)
+			}
+		}
+	}
+
+	var sorted []*Process
+	visited := make(map[string]bool)
+	visiting := make(map[string]int)
+
+	var path []string
+	var visit func(process *Process) error
+	visit = func(process *Process) error {
+		if visited[process.Name] {
+			return nil
+		}
+
+		if start, ok := visiting[process.Name]; ok {
+			cycle := append(path[start:], process.Name)
+			return fmt.Errorf("dependency cycle between processes: %s", strings.Join(cycle, " -> "))
+		}
+
+		visiting[process.Name] = len(path)
+		path = append(path, process.Name)
+
+		for _, dep := range process.DependsOn {
+			err := visit(byName[dep.Name])
+			if err != nil {
+				return err
+			}
+		}
+
+		path = path[:len(path)-1]
+		delete(visiting, process.Name)
+
+		visited[process.Name] = true
+		sorted = append(sorted, process)
+
+		return nil
+	}
+
+	for _, process := range processes {
+		err := visit(process)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
+
+// processesByName indexes the processes by their names, which dependencies
+// and restart triggers refer to them by.
+func processesByName(processes []*Process) (map[string]*Process, error) {
+	byName := make(map[string]*Process)
+	for i, process := range processes {
+		if process.Name == "" {
+			return nil, fmt.Errorf("process %d has no name", i+1)
+		}
+
+		if _, ok := byName[process.Name]; ok {
+			return nil, fmt.Errorf("more than one process is named '%s'", process.Name)
+		}
+
+		byName[process.Name] = process
+	}
+
+	return byName, nil
+}
+
+// Dependents returns every process that depends on the named process,
+// directly or through other processes, in the order they should be started.
+func Dependents(processes []*Process, name string) []*Process {
+	sorted, err := SortProcesses(processes)
+	if err != nil {
+		return nil
+	}
+
+	affected := map[string]bool{name: true}
+
+	var dependents []*Process
+	for _, process := range sorted {
+		for _, dep := range process.DependsOn {
+			if affected[dep.Name] {
+				affected[process.Name] = true
+				dependents = append(dependents, process)
+
+				break
+			}
+		}
+	}
+
+	return dependents
+}
+
+// StartProcesses starts each process once the processes it depends on have
+// met their conditions, starting processes that don't depend on each other
+// at the same time. If a process fails to start, the processes that depend
+// on it aren't started and the first error is returned. Once the context is
+// done, no more processes are started and the ones starting stop waiting to
+// be ready.
+func StartProcesses(ctx context.Context, processes []*Process) error {
+	_, err := SortProcesses(processes)
+	if err != nil {
+		return err
+	}
+
+	byName, err := processesByName(processes)
+	if err != nil {
+		return err
+	}
+
+	type progress struct {
+		started chan struct{}
+		ready   chan struct{}
+	}
+
+	states := make(map[*Process]*progress)
+	for _, process := range processes {
+		states[process] = &progress{
+			started: make(chan struct{}),
+			ready:   make(chan struct{}),
+		}
+	}
+
+	failed := make(chan struct{})
+	var failure sync.Once
+	var firstErr error
+
+	var wg sync.WaitGroup
+	for _, process := range processes {
+		wg.Add(1)
+
+		go func(process *Process) {
+			defer wg.Done()
+
+			for _, dep := range process.DependsOn {
+				dependency := states[byName[dep.Name]]
+
+				wait := dependency.ready
+				if dep.Condition == "" || dep.Condition == DependsOnStarted {
+					wait = dependency.started
+				}
+
+				select {
+				case <-wait:
+				case <-failed:
+					return
+				case <-ctx.Done():
+					failure.Do(func() {
+						firstErr = ctx.Err()
+						close(failed)
+					})
+
+					return
+				}
+			}
+
+			state := states[process]
+
+			err := process.startWith(ctx, func() {
+				close(state.started)
+			})
+			if err != nil {
+				failure.Do(func() {
+					firstErr = fmt.Errorf("error starting '%s': %s", process.Name, err)
+					close(failed)
+				})
+
+				return
+			}
+
+			close(state.ready)
+		}(process)
+	}
+
+	wg.Wait()
+
+	return firstErr
+}
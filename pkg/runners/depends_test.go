@@ -0,0 +1,234 @@
+package runners_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/iplay88keys/watchtower/pkg/runners"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Dependencies", func() {
+	names := func(processes []*runners.Process) []string {
+		var result []string
+		for _, process := range processes {
+			result = append(result, process.Name)
+		}
+
+		return result
+	}
+
+	Describe("SortProcesses", func() {
+		It("orders processes after the processes they depend on", func() {
+			sorted, err := runners.SortProcesses([]*runners.Process{
+				{Name: "backend", Type: "background", DependsOn: []runners.Dependency{
+					{Name: "db-migrate", Condition: runners.DependsOnCompleted},
+					{Name: "mock-auth", Condition: runners.DependsOnReady},
+				}},
+				{Name: "frontend", Type: "background"},
+				{Name: "db-migrate", Type: "task"},
+				{Name: "mock-auth", Type: "background"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(names(sorted)).To(Equal([]string{"db-migrate", "mock-auth", "backend", "frontend"}))
+		})
+
+		It("returns an error for processes without a name", func() {
+			_, err := runners.SortProcesses([]*runners.Process{
+				{Name: "a"},
+				{Type: "task"},
+			})
+			Expect(err).To(MatchError("process 2 has no name"))
+		})
+
+		It("returns an error for processes that share a name", func() {
+			_, err := runners.SortProcesses([]*runners.Process{
+				{Name: "a", Type: "task"},
+				{Name: "a", Type: "background"},
+			})
+			Expect(err).To(MatchError("more than one process is named 'a'"))
+		})
+
+		It("returns an error for dependency cycles", func() {
+			_, err := runners.SortProcesses([]*runners.Process{
+				{Name: "a", DependsOn: []runners.Dependency{{Name: "b"}}},
+				{Name: "b", DependsOn: []runners.Dependency{{Name: "c"}}},
+				{Name: "c", DependsOn: []runners.Dependency{{Name: "a"}}},
+			})
+			Expect(err).To(MatchError("dependency cycle between processes: a -> b -> c -> a"))
+		})
+
+		It("returns an error for unknown dependencies", func() {
+			_, err := runners.SortProcesses([]*runners.Process{
+				{Name: "a", DependsOn: []runners.Dependency{{Name: "b"}}},
+			})
+			Expect(err).To(MatchError("process 'a' depends on unknown process 'b'"))
+		})
+
+		It("returns an error when depending on a background process completing", func() {
+			_, err := runners.SortProcesses([]*runners.Process{
+				{Name: "a", Type: "task", DependsOn: []runners.Dependency{{Name: "b", Condition: runners.DependsOnCompleted}}},
+				{Name: "b", Type: "background"},
+			})
+			Expect(err).To(MatchError("process 'a' can only depend on 'b' completing if it is a task"))
+		})
+
+		It("returns an error for unknown conditions", func() {
+			_, err := runners.SortProcesses([]*runners.Process{
+				{Name: "a", DependsOn: []runners.Dependency{{Name: "b", Condition: "eventually"}}},
+				{Name: "b"},
+			})
+			Expect(err).To(MatchError("unknown condition for process 'a' depending on 'b': eventually"))
+		})
+	})
+
+	It("finds the processes that depend on a process", func() {
+		dependents := runners.Dependents([]*runners.Process{
+			{Name: "frontend", DependsOn: []runners.Dependency{{Name: "backend"}}},
+			{Name: "backend", DependsOn: []runners.Dependency{{Name: "db"}}},
+			{Name: "db"},
+			{Name: "docs"},
+		}, "db")
+		Expect(names(dependents)).To(Equal([]string{"backend", "frontend"}))
+	})
+
+	Describe("StartProcesses", func() {
+		var (
+			stdout *os.File
+			r, w   *os.File
+			dir    string
+			log    string
+		)
+
+		BeforeEach(func() {
+			var err error
+			r, w, err = os.Pipe()
+			Expect(err).ToNot(HaveOccurred())
+
+			stdout = os.Stdout
+			os.Stdout = w
+
+			dir, err = ioutil.TempDir("", "depends")
+			Expect(err).ToNot(HaveOccurred())
+
+			log = filepath.Join(dir, "log")
+		})
+
+		AfterEach(func() {
+			err := w.Close()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = ioutil.ReadAll(r)
+			Expect(err).ToNot(HaveOccurred())
+
+			os.Stdout = stdout
+			os.RemoveAll(dir)
+		})
+
+		It("starts processes once their dependencies meet their conditions", func() {
+			processes := []*runners.Process{{
+				Name:     "backend",
+				Type:     "background",
+				StartCmd: "echo backend >> " + log + "; sleep 30",
+				DependsOn: []runners.Dependency{
+					{Name: "db-migrate", Condition: runners.DependsOnCompleted},
+					{Name: "mock-auth", Condition: runners.DependsOnReady},
+				},
+			}, {
+				Name:     "db-migrate",
+				Type:     "task",
+				StartCmd: "sleep 0.2; echo db-migrate >> " + log,
+			}, {
+				Name:     "mock-auth",
+				Type:     "background",
+				StartCmd: "echo mock-auth >> " + log + "; sleep 0.4; echo ready; sleep 30",
+				Ready: runners.Ready{
+					Log: "ready",
+				},
+			}}
+
+			err := runners.StartProcesses(context.Background(), processes)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(func() string {
+				contents, _ := ioutil.ReadFile(log)
+				return string(contents)
+			}, 5).Should(Equal("mock-auth\ndb-migrate\nbackend\n"))
+
+			for _, process := range processes {
+				Expect(process.Stop()).To(Succeed())
+			}
+		})
+
+		It("returns an error instead of starting processes without a name", func() {
+			processes := []*runners.Process{{
+				Type:     "task",
+				StartCmd: "echo first >> " + log,
+			}, {
+				Type:     "task",
+				StartCmd: "echo second >> " + log,
+			}}
+
+			err := runners.StartProcesses(context.Background(), processes)
+			Expect(err).To(MatchError("process 1 has no name"))
+
+			_, err = os.Stat(log)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("stops starting processes once the context is done", func() {
+			processes := []*runners.Process{{
+				Name:      "backend",
+				Type:      "task",
+				StartCmd:  "echo backend >> " + log,
+				DependsOn: []runners.Dependency{{Name: "api", Condition: runners.DependsOnReady}},
+			}, {
+				Name:     "api",
+				Type:     "background",
+				StartCmd: "sleep 30",
+				Ready: runners.Ready{
+					Probe: runners.Probe{
+						TCP: "127.0.0.1:1",
+					},
+				},
+			}}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			err := runners.StartProcesses(ctx, processes)
+			Expect(err).To(MatchError(ContainSubstring("context deadline exceeded")))
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+
+			_, err = os.Stat(log)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+
+			Expect(processes[1].Stop()).To(Succeed())
+		})
+
+		It("doesn't start processes whose dependencies failed to start", func() {
+			processes := []*runners.Process{{
+				Name:      "backend",
+				Type:      "task",
+				StartCmd:  "echo backend >> " + log,
+				DependsOn: []runners.Dependency{{Name: "db-migrate"}},
+			}, {
+				Name:     "db-migrate",
+				Type:     "task",
+				StartCmd: "exit 1",
+			}}
+
+			err := runners.StartProcesses(context.Background(), processes)
+			Expect(err).To(MatchError("error starting 'db-migrate': exit status 1"))
+
+			_, err = os.Stat(log)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+})
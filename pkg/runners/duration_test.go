@@ -0,0 +1,38 @@
+package runners_test
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/iplay88keys/watchtower/pkg/runners"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Duration", func() {
+	It("unmarshals duration strings", func() {
+		var d runners.Duration
+		err := json.Unmarshal([]byte(`"1m30s"`), &d)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(time.Duration(d)).To(Equal(90 * time.Second))
+	})
+
+	It("marshals back to a duration string", func() {
+		out, err := json.Marshal(runners.Duration(1500 * time.Millisecond))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(out)).To(Equal(`"1.5s"`))
+	})
+
+	It("returns an error for durations that are not strings", func() {
+		var d runners.Duration
+		err := json.Unmarshal([]byte(`10`), &d)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns an error for invalid durations", func() {
+		var d runners.Duration
+		err := json.Unmarshal([]byte(`"soon"`), &d)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,120 @@
+package runners
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Environment holds the environment variables added to the environment a
+// command inherits. Values can refer to other variables as ${VAR}.
+type Environment struct {
+	// EnvFile lists files of KEY=VALUE lines, loaded in order.
+	EnvFile []string `json:"envFile"`
+
+	// Env sets variables after the env files have been loaded.
+	Env map[string]string `json:"env"`
+}
+
+func (e Environment) empty() bool {
+	return len(e.EnvFile) == 0 && len(e.Env) == 0
+}
+
+// environ returns base with the env files and variables added. Each value
+// is expanded against the variables set before it, with the env variables
+// set in order of their names.
+func (e Environment) environ(base []string) ([]string, error) {
+	vars := make(map[string]string)
+	var names []string
+
+	set := func(name, value string, expand bool) {
+		if _, ok := vars[name]; !ok {
+			names = append(names, name)
+		}
+
+		if expand {
+			value = os.Expand(value, func(ref string) string {
+				return vars[ref]
+			})
+		}
+
+		vars[name] = value
+	}
+
+	for _, entry := range base {
+		if i := strings.Index(entry, "="); i > 0 {
+			name := entry[:i]
+			if _, ok := vars[name]; !ok {
+				names = append(names, name)
+			}
+
+			vars[name] = entry[i+1:]
+		}
+	}
+
+	for _, file := range e.EnvFile {
+		err := loadEnvFile(file, set)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var keys []string
+	for name := range e.Env {
+		keys = append(keys, name)
+	}
+
+	sort.Strings(keys)
+
+	for _, name := range keys {
+		set(name, e.Env[name], true)
+	}
+
+	environ := make([]string, 0, len(names))
+	for _, name := range names {
+		environ = append(environ, name+"="+vars[name])
+	}
+
+	return environ, nil
+}
+
+// loadEnvFile reads KEY=VALUE lines from the file, skipping blank lines and
+// comments. Lines may start with "export", and values may be quoted.
+func loadEnvFile(path string, set func(name, value string, expand bool)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("couldn't load env file: %s", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		text = strings.TrimSpace(strings.TrimPrefix(text, "export "))
+
+		i := strings.Index(text, "=")
+		if i <= 0 {
+			return fmt.Errorf("invalid line %d in env file '%s': %s", line, path, text)
+		}
+
+		name := strings.TrimSpace(text[:i])
+		value := strings.TrimSpace(text[i+1:])
+
+		// Single quoted values are used as they are, without expansion.
+		expand := true
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			expand = value[0] == '"'
+			value = value[1 : len(value)-1]
+		}
+
+		set(name, value, expand)
+	}
+
+	return scanner.Err()
+}
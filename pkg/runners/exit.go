@@ -0,0 +1,119 @@
+package runners
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// ExitStatus records how a background process exited.
+type ExitStatus struct {
+	Code    int
+	Signal  Signal
+	Runtime time.Duration
+}
+
+func newExitStatus(state *os.ProcessState, runtime time.Duration) ExitStatus {
+	status := ExitStatus{
+		Code:    -1,
+		Runtime: runtime,
+	}
+
+	if state == nil {
+		return status
+	}
+
+	status.Code = state.ExitCode()
+
+	waitStatus, ok := state.Sys().(syscall.WaitStatus)
+	if ok && waitStatus.Signaled() {
+		status.Signal = Signal(waitStatus.Signal())
+	}
+
+	return status
+}
+
+func (s ExitStatus) String() string {
+	runtime := s.Runtime.Round(time.Millisecond)
+
+	if s.Signal != 0 {
+		return fmt.Sprintf("signal %s after %s", s.Signal, runtime)
+	}
+
+	return fmt.Sprintf("code %d after %s", s.Code, runtime)
+}
+
+// Running returns whether the background process is still running.
+func (p *Process) Running() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.process != nil
+}
+
+// Active returns whether one of the process's commands or its background
+// process is running and, if not, when the last of them stopped.
+func (p *Process) Active() (bool, time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.commands > 0 || p.process != nil, p.idleSince
+}
+
+// LastExit returns how the background process last exited, or nil if it
+// hasn't exited since Watchtower started.
+func (p *Process) LastExit() *ExitStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.lastExit == nil {
+		return nil
+	}
+
+	status := *p.lastExit
+
+	return &status
+}
+
+func (p *Process) running() (*exec.Cmd, chan struct{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.process, p.exited
+}
+
+// reap waits for a background process to exit so that it doesn't linger as
+// a zombie, then records how it exited and closes exited. A process that
+// exited without being stopped is restarted if its restart policy says so.
+func (p *Process) reap(cmd *exec.Cmd, started time.Time, exited chan struct{}) {
+	cmd.Wait()
+
+	now := time.Now()
+	status := newExitStatus(cmd.ProcessState, now.Sub(started))
+
+	// The exit is reported with the lock held, so that anything that sees
+	// the new state also sees it reported.
+	p.mu.Lock()
+	p.lastExit = &status
+	p.idleSince = now
+	fmt.Printf("'%s' exited with %s\n", p.Name, status)
+
+	if p.process == cmd {
+		p.process = nil
+		p.exited = nil
+
+		if !p.stopping && p.shouldRestart(status) {
+			backoff, restarting := p.scheduleRestart(now)
+			if restarting {
+				fmt.Printf("Restarting '%s' in %s\n", p.Name, backoff)
+			} else {
+				fmt.Printf("'%s' is crash looping, it won't be restarted again until a trigger restarts it\n", p.Name)
+			}
+		}
+	}
+	p.mu.Unlock()
+
+	close(exited)
+}
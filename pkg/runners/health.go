@@ -0,0 +1,174 @@
+package runners
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultHealthInterval         = 10 * time.Second
+	defaultHealthTimeout          = 5 * time.Second
+	defaultHealthFailureThreshold = 3
+
+	// healthHistoryLength is how many health check results are kept for
+	// the process status.
+	healthHistoryLength = 10
+)
+
+// HealthCheck periodically checks that a running background process is
+// still serving, and restarts it once it fails too many checks in a row.
+type HealthCheck struct {
+	Probe
+
+	// Interval is how long to wait between checks. Defaults to 10 seconds.
+	Interval Duration `json:"interval"`
+
+	// Timeout is how long each check can take. Defaults to 5 seconds.
+	Timeout Duration `json:"timeout"`
+
+	// FailureThreshold is how many checks in a row have to fail before the
+	// process is restarted. Defaults to 3.
+	FailureThreshold int `json:"failureThreshold"`
+
+	// RunCleanup runs the cleanup command when restarting the process, the
+	// same as it does for a restart trigger.
+	RunCleanup bool `json:"runCleanup"`
+}
+
+// HealthResult is the result of a single health check.
+type HealthResult struct {
+	Time    time.Time
+	Healthy bool
+	Error   string
+}
+
+// ProcessStatus describes the state of a process.
+type ProcessStatus struct {
+	Name     string
+	Running  bool
+	Failed   bool
+	LastExit *ExitStatus
+
+	// Health holds the most recent health check results, oldest first.
+	Health []HealthResult
+}
+
+// Status returns the current state of the process.
+func (p *Process) Status() ProcessStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := ProcessStatus{
+		Name:    p.Name,
+		Running: p.process != nil,
+		Failed:  p.failed,
+		Health:  append([]HealthResult(nil), p.health...),
+	}
+
+	if p.lastExit != nil {
+		lastExit := *p.lastExit
+		status.LastExit = &lastExit
+	}
+
+	return status
+}
+
+// monitor runs the health checks for a launch of the process until it
+// exits, restarting it once the checks have failed too many times in a row.
+func (p *Process) monitor(exited chan struct{}) {
+	interval := defaultHealthInterval
+	if p.HealthCheck.Interval > 0 {
+		interval = time.Duration(p.HealthCheck.Interval)
+	}
+
+	timeout := defaultHealthTimeout
+	if p.HealthCheck.Timeout > 0 {
+		timeout = time.Duration(p.HealthCheck.Timeout)
+	}
+
+	threshold := defaultHealthFailureThreshold
+	if p.HealthCheck.FailureThreshold > 0 {
+		threshold = p.HealthCheck.FailureThreshold
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-exited:
+			return
+		case <-ticker.C:
+		}
+
+		err := p.checkProbe(context.Background(), p.HealthCheck.Probe, timeout)
+
+		result := HealthResult{
+			Time:    time.Now(),
+			Healthy: err == nil,
+		}
+
+		if err != nil {
+			result.Error = err.Error()
+			failures++
+		} else {
+			failures = 0
+		}
+
+		p.mu.Lock()
+		p.health = append(p.health, result)
+		if len(p.health) > healthHistoryLength {
+			p.health = p.health[len(p.health)-healthHistoryLength:]
+		}
+		stopping := p.stopping
+		p.mu.Unlock()
+
+		if err != nil {
+			fmt.Printf("Health check failed for '%s': %s\n", p.Name, err)
+		}
+
+		if failures < threshold || stopping {
+			continue
+		}
+
+		p.restartHealth(exited, failures)
+
+		// Stopping and starting the process launches it again with checks
+		// of its own, but a restart command leaves this launch running, so
+		// the checks carry on for it.
+		select {
+		case <-exited:
+			return
+		default:
+			failures = 0
+		}
+	}
+}
+
+// restartHealth restarts a launch of the process that failed its health
+// checks. Another restart or teardown might have run while waiting for the
+// restart lock, so nothing is done if the launch has already exited or the
+// process is being stopped.
+func (p *Process) restartHealth(exited chan struct{}, failures int) {
+	ctx, done := p.beginRestart()
+	defer done()
+
+	select {
+	case <-exited:
+		return
+	default:
+	}
+
+	if p.isStopping() {
+		return
+	}
+
+	fmt.Printf("'%s' failed %d health checks in a row, restarting it\n", p.Name, failures)
+
+	err := p.restart(ctx, p.HealthCheck.RunCleanup)
+	if err != nil {
+		fmt.Printf("Error restarting '%s': %s\n", p.Name, err)
+	}
+}
@@ -0,0 +1,242 @@
+package runners_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/iplay88keys/watchtower/pkg/runners"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HealthCheck", func() {
+	var (
+		stdout *os.File
+		r, w   *os.File
+	)
+
+	BeforeEach(func() {
+		stdout = os.Stdout
+
+		var err error
+		r, w, err = os.Pipe()
+		Expect(err).ToNot(HaveOccurred())
+		os.Stdout = w
+	})
+
+	readOutput := func() string {
+		err := w.Close()
+		Expect(err).ToNot(HaveOccurred())
+
+		out, err := ioutil.ReadAll(r)
+		Expect(err).ToNot(HaveOccurred())
+
+		os.Stdout = stdout
+
+		return string(out)
+	}
+
+	It("records the health of a running process", func() {
+		proc := runners.Process{
+			Name:     "test",
+			Type:     "background",
+			StartCmd: "sleep 30",
+			HealthCheck: runners.HealthCheck{
+				Probe: runners.Probe{
+					Command: "true",
+				},
+				Interval: runners.Duration(50 * time.Millisecond),
+			},
+		}
+
+		err := proc.Start()
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(func() int {
+			return len(proc.Status().Health)
+		}).Should(BeNumerically(">=", 2))
+
+		err = proc.Stop()
+		Expect(err).ToNot(HaveOccurred())
+
+		readOutput()
+
+		status := proc.Status()
+		Expect(status.Name).To(Equal("test"))
+		Expect(status.Running).To(BeFalse())
+		Expect(status.Health[0].Healthy).To(BeTrue())
+		Expect(status.Health[0].Error).To(BeEmpty())
+	})
+
+	It("restarts a process once it fails too many health checks in a row", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+
+		addr := listener.Addr().String()
+		listener.Close()
+
+		proc := runners.Process{
+			Name:     "test",
+			Type:     "background",
+			StartCmd: "sleep 30",
+			HealthCheck: runners.HealthCheck{
+				Probe: runners.Probe{
+					TCP: addr,
+				},
+				Interval:         runners.Duration(100 * time.Millisecond),
+				FailureThreshold: 2,
+			},
+		}
+
+		err = proc.Start()
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(func() bool {
+			status := proc.Status()
+			return status.Running && status.LastExit != nil
+		}, 5).Should(BeTrue())
+
+		err = proc.Stop()
+		Expect(err).ToNot(HaveOccurred())
+
+		out := readOutput()
+
+		status := proc.Status()
+		Expect(status.LastExit.Signal).To(Equal(runners.Signal(syscall.SIGTERM)))
+		Expect(status.Health).ToNot(BeEmpty())
+		Expect(status.Health[0].Healthy).To(BeFalse())
+		Expect(status.Health[0].Error).To(HavePrefix("couldn't connect to '" + addr + "'"))
+
+		Expect(out).To(ContainSubstring("'test' failed 2 health checks in a row, restarting it\n"))
+		Expect(strings.Count(out, "Running 'test' start command: 'sleep 30'")).To(Equal(2))
+	})
+
+	It("doesn't start a process again once teardown has stopped it during a health check restart", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+
+		addr := listener.Addr().String()
+		listener.Close()
+
+		dir, err := ioutil.TempDir("", "health")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		marker := filepath.Join(dir, "stopping")
+
+		proc := &runners.Process{
+			Name:        "test",
+			Type:        "background",
+			StartCmd:    "sleep 30",
+			StopCmd:     "touch " + marker + " && sleep 0.5",
+			StopTimeout: runners.Duration(100 * time.Millisecond),
+			HealthCheck: runners.HealthCheck{
+				Probe: runners.Probe{
+					TCP: addr,
+				},
+				Interval:         runners.Duration(50 * time.Millisecond),
+				FailureThreshold: 1,
+			},
+		}
+
+		err = proc.Start()
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(marker, 5).Should(BeAnExistingFile())
+
+		err = runners.StopProcesses(context.Background(), []*runners.Process{proc})
+		Expect(err).ToNot(HaveOccurred())
+
+		Consistently(proc.Running, "500ms").Should(BeFalse())
+
+		out := readOutput()
+		Expect(strings.Count(out, "failed 1 health checks in a row, restarting it")).To(Equal(1))
+	})
+
+	It("cancels a health check restart once the teardown context is done", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+
+		addr := listener.Addr().String()
+		listener.Close()
+
+		dir, err := ioutil.TempDir("", "health")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		marker := filepath.Join(dir, "stopping")
+
+		proc := &runners.Process{
+			Name:     "test",
+			Type:     "background",
+			StartCmd: "sleep 30",
+			StopCmd:  "touch " + marker + " && sleep 30",
+			HealthCheck: runners.HealthCheck{
+				Probe: runners.Probe{
+					TCP: addr,
+				},
+				Interval:         runners.Duration(50 * time.Millisecond),
+				FailureThreshold: 1,
+			},
+		}
+
+		err = proc.Start()
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(marker, 5).Should(BeAnExistingFile())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		runners.StopProcesses(ctx, []*runners.Process{proc})
+		Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+
+		Consistently(proc.Running, "500ms").Should(BeFalse())
+
+		readOutput()
+	})
+
+	It("keeps checking a process that was restarted with its restart command", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+
+		addr := listener.Addr().String()
+		listener.Close()
+
+		proc := &runners.Process{
+			Name:       "test",
+			Type:       "background",
+			StartCmd:   "sleep 30",
+			RestartCmd: "true",
+			HealthCheck: runners.HealthCheck{
+				Probe: runners.Probe{
+					TCP: addr,
+				},
+				Interval:         runners.Duration(50 * time.Millisecond),
+				FailureThreshold: 1,
+			},
+		}
+
+		err = proc.Start()
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(func() int {
+			return len(proc.Status().Health)
+		}, 5).Should(BeNumerically(">=", 3))
+
+		err = proc.Stop()
+		Expect(err).ToNot(HaveOccurred())
+
+		out := readOutput()
+		Expect(strings.Count(out, "Running 'test' restart command: 'true'")).To(BeNumerically(">=", 2))
+	})
+})
+
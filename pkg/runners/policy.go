@@ -0,0 +1,120 @@
+package runners
+
+import (
+	"fmt"
+	"time"
+)
+
+// Restart policies decide whether a background process is started again
+// when it exits without being stopped by Watchtower.
+const (
+	RestartNever     = "never"
+	RestartAlways    = "always"
+	RestartOnFailure = "on-failure"
+)
+
+const (
+	defaultRestartBackoff = time.Second
+	maxRestartBackoff     = time.Minute
+	defaultMaxRestarts    = 5
+	defaultRestartWindow  = time.Minute
+)
+
+// Failed returns whether the background process was crash looping and has
+// stopped being restarted. It is cleared by the next Restart.
+func (p *Process) Failed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.failed
+}
+
+// shouldRestart returns whether the restart policy restarts a process that
+// exited with the status.
+func (p *Process) shouldRestart(status ExitStatus) bool {
+	switch p.RestartPolicy {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return status.Code != 0 || status.Signal != 0
+	default:
+		return false
+	}
+}
+
+// scheduleRestart starts the process again after a backoff that doubles
+// with each restart in the restart window. A process that has already been
+// restarted the maximum number of times within the window is crash looping,
+// so it is marked as failed instead. It must be called with the lock held.
+func (p *Process) scheduleRestart(now time.Time) (time.Duration, bool) {
+	window := defaultRestartWindow
+	if p.RestartWindow > 0 {
+		window = time.Duration(p.RestartWindow)
+	}
+
+	maxRestarts := defaultMaxRestarts
+	if p.MaxRestarts > 0 {
+		maxRestarts = p.MaxRestarts
+	}
+
+	var recent []time.Time
+	for _, restart := range p.restarts {
+		if now.Sub(restart) < window {
+			recent = append(recent, restart)
+		}
+	}
+
+	p.restarts = recent
+
+	if len(p.restarts) >= maxRestarts {
+		p.failed = true
+
+		return 0, false
+	}
+
+	backoff := defaultRestartBackoff
+	if p.RestartBackoff > 0 {
+		backoff = time.Duration(p.RestartBackoff)
+	}
+
+	for i := 0; i < len(p.restarts) && backoff < maxRestartBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > maxRestartBackoff {
+		backoff = maxRestartBackoff
+	}
+
+	p.restarts = append(p.restarts, now)
+	p.restartTimer = time.AfterFunc(backoff, p.autoRestart)
+
+	return backoff, true
+}
+
+func (p *Process) autoRestart() {
+	ctx, done := p.beginRestart()
+	defer done()
+
+	p.mu.Lock()
+	cancelled := p.stopping || p.tearingDown || p.restartTimer == nil
+	p.restartTimer = nil
+	p.mu.Unlock()
+
+	if cancelled {
+		return
+	}
+
+	err := p.start(ctx, nil)
+	if err != nil {
+		fmt.Printf("Error restarting '%s': %s\n", p.Name, err)
+	}
+}
+
+// cancelRestart stops a scheduled restart, so that a process Watchtower was
+// asked to stop stays stopped. It must be called with the lock held.
+func (p *Process) cancelRestart() {
+	if p.restartTimer != nil {
+		p.restartTimer.Stop()
+		p.restartTimer = nil
+	}
+}
@@ -0,0 +1,122 @@
+package runners
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// Probe checks whether a process is serving. Every check that is set has to
+// pass.
+type Probe struct {
+	// TCP is an address such as "localhost:8080" that has to accept
+	// connections.
+	TCP string `json:"tcp"`
+
+	// HTTP is a URL that has to respond with Status, or with any status
+	// below 400 if Status isn't set.
+	HTTP   string `json:"http"`
+	Status int    `json:"status"`
+
+	// Command is a command that has to exit successfully.
+	Command string `json:"command"`
+}
+
+func (p Probe) empty() bool {
+	return p.TCP == "" && p.HTTP == "" && p.Command == ""
+}
+
+// checkProbe runs the checks of one of the process's probes. A probe
+// command runs with the process's environment, so that it sees the same
+// settings as the process it checks.
+func (p *Process) checkProbe(ctx context.Context, probe Probe, timeout time.Duration) error {
+	var env []string
+	if !p.Environment.empty() {
+		var err error
+		env, err = p.Environment.environ(os.Environ())
+		if err != nil {
+			return err
+		}
+	}
+
+	return probe.check(ctx, timeout, env)
+}
+
+// check runs each of the probe's checks, giving each one the timeout to
+// finish. They are abandoned once the context is done. The command is run
+// with env, or the inherited environment if it is empty, in its own process
+// group so that everything it started is killed with it.
+func (p Probe) check(ctx context.Context, timeout time.Duration, env []string) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if p.TCP != "" {
+		var dialer net.Dialer
+
+		conn, err := dialer.DialContext(ctx, "tcp", p.TCP)
+		if err != nil {
+			return fmt.Errorf("couldn't connect to '%s': %s", p.TCP, err)
+		}
+
+		conn.Close()
+	}
+
+	if p.HTTP != "" {
+		client := http.Client{Timeout: timeout}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.HTTP, nil)
+		if err != nil {
+			return fmt.Errorf("couldn't get '%s': %s", p.HTTP, err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("couldn't get '%s': %s", p.HTTP, err)
+		}
+
+		resp.Body.Close()
+
+		if p.Status != 0 && resp.StatusCode != p.Status {
+			return fmt.Errorf("'%s' responded with %d instead of %d", p.HTTP, resp.StatusCode, p.Status)
+		}
+
+		if p.Status == 0 && resp.StatusCode >= 400 {
+			return fmt.Errorf("'%s' responded with %d", p.HTTP, resp.StatusCode)
+		}
+	}
+
+	if p.Command != "" {
+		cmd := exec.Command("bash", "-c", p.Command)
+		cmd.Env = env
+		setProcessGroup(cmd)
+
+		err := cmd.Start()
+		if err != nil {
+			return fmt.Errorf("'%s' failed: %s", p.Command, err)
+		}
+
+		done := make(chan error, 1)
+		go func() {
+			done <- cmd.Wait()
+		}()
+
+		select {
+		case err = <-done:
+		case <-ctx.Done():
+			killProcessGroup(cmd)
+			<-done
+
+			return fmt.Errorf("'%s' didn't finish within %s", p.Command, timeout)
+		}
+
+		if err != nil {
+			return fmt.Errorf("'%s' failed: %s", p.Command, err)
+		}
+	}
+
+	return nil
+}
@@ -2,25 +2,83 @@ package runners
 
 import (
     "bytes"
+    "context"
     "fmt"
     "io"
     "os"
     "os/exec"
     "strings"
+    "sync"
+    "syscall"
+    "time"
 )
 
 type execContext = func(name string, arg ...string) *exec.Cmd
 
+const (
+    // defaultStopTimeout is how long a background process is given to exit
+    // after being sent its stop signal, before it is killed.
+    defaultStopTimeout = 10 * time.Second
+
+    // stopVerifyTimeout is how long the processes started by a background
+    // process are given to exit after it has been killed.
+    stopVerifyTimeout = 2 * time.Second
+)
+
 type Process struct {
-    Name       string `json:"name"`
-    Type       string `json:"type"`
-    StartCmd   string `json:"start"`
-    StopCmd    string `json:"stop"`
-    RestartCmd string `json:"restart"`
-    CleanupCmd string `json:"cleanup"`
+    Name       string   `json:"name"`
+    Type       string   `json:"type"`
+    StartCmd   string   `json:"start"`
+    StopCmd    string   `json:"stop"`
+    RestartCmd string   `json:"restart"`
+    CleanupCmd string   `json:"cleanup"`
+    Timeouts   Timeouts `json:"timeouts"`
+
+    StopSignal  Signal   `json:"stopSignal"`
+    StopTimeout Duration `json:"stopTimeout"`
+
+    RestartPolicy  string   `json:"restartPolicy"`
+    RestartBackoff Duration `json:"restartBackoff"`
+    MaxRestarts    int      `json:"maxRestarts"`
+    RestartWindow  Duration `json:"restartWindow"`
+
+    Ready       Ready        `json:"ready"`
+    DependsOn   []Dependency `json:"dependsOn"`
+    HealthCheck HealthCheck  `json:"healthCheck"`
+
+    Environment
+
+    // RestartOnEnvFileChange restarts the process when one of its env
+    // files changes.
+    RestartOnEnvFileChange bool `json:"restartOnEnvFileChange"`
+
+    // Outputs are globs of files the process writes. Watches ignore changes
+    // to them while it runs and shortly after.
+    Outputs []string `json:"outputs"`
 
     execContext execContext
-    process     *exec.Cmd
+
+    mu           sync.Mutex
+    process      *exec.Cmd
+    exited       chan struct{}
+    lastExit     *ExitStatus
+    launched     bool
+    stopping     bool
+    restarts     []time.Time
+    restartTimer *time.Timer
+    failed       bool
+    health       []HealthResult
+    commands     int
+    idleSince    time.Time
+
+    // restarting holds the one slot that serializes restarts and teardown,
+    // so that only one of them launches or stops the process at a time.
+    // restartCancel cancels the restart holding it, which teardown does
+    // instead of waiting once its context is done.
+    restarting    chan struct{}
+    restartCancel context.CancelFunc
+    tearingDown   bool
+    abortRestart  bool
 }
 
 func (p *Process) UpdateExecContext(context execContext) {
@@ -28,55 +86,259 @@ func (p *Process) UpdateExecContext(context execContext) {
 }
 
 func (p *Process) Start() error {
+    return p.startWith(context.Background(), nil)
+}
+
+// startWith starts the process like Start, calling started once the start
+// command has started and before waiting for the process to be ready. Once
+// the context is done, a start task is killed and the ready checks stop.
+func (p *Process) startWith(ctx context.Context, started func()) error {
+    p.mu.Lock()
+    p.stopping = false
+    p.mu.Unlock()
+
+    return p.start(ctx, started)
+}
+
+func (p *Process) start(ctx context.Context, started func()) error {
     if p.execContext == nil {
         p.execContext = exec.Command
     }
 
-    if p.process != nil && p.process.Process != nil {
+    notify := started
+    started = func() {
+        p.mu.Lock()
+        p.launched = true
+        p.mu.Unlock()
+
+        if notify != nil {
+            notify()
+        }
+    }
+
+    if p.Running() {
         fmt.Println("Process is already running")
+        started()
         return nil
     }
 
-    err := p.execute(p.Type, p.StartCmd, "start")
+    if p.Type != "background" || p.Ready.empty() {
+        err := p.execute(ctx, p.Type, p.StartCmd, "start", time.Duration(p.Timeouts.Start))
+        if err != nil {
+            return err
+        }
+
+        started()
+        return nil
+    }
+
+    var logs *logMatcher
+    var outputs []io.Writer
+    if p.Ready.Log != "" {
+        var err error
+        logs, err = newLogMatcher(p.Ready.Log)
+        if err != nil {
+            return err
+        }
+
+        outputs = append(outputs, logs)
+    }
+
+    err := p.execute(ctx, p.Type, p.StartCmd, "start", time.Duration(p.Timeouts.Start), outputs...)
     if err != nil {
         return err
     }
 
-    return nil
+    started()
+
+    cmd, exited := p.running()
+    if exited == nil {
+        return fmt.Errorf("'%s' exited before it was ready", p.Name)
+    }
+
+    err = p.waitReady(ctx, logs, exited)
+    if err == nil {
+        return nil
+    }
+
+    // A process that isn't ready is killed, so that starting it again
+    // launches it again instead of finding it already running.
+    select {
+    case <-exited:
+        return err
+    default:
+    }
+
+    fmt.Printf("Killing '%s' as it isn't ready\n", p.Name)
+
+    killErr := p.forceKill(cmd, exited)
+    if killErr != nil {
+        return fmt.Errorf("%s; %s", err, killErr)
+    }
+
+    return err
 }
 
 func (p *Process) Stop() error {
+    return p.stop(context.Background())
+}
+
+// stop stops the process. Once the context is done, whatever is still
+// running is killed straight away instead of being given time to exit.
+func (p *Process) stop(ctx context.Context) error {
     if p.execContext == nil {
         p.execContext = exec.Command
     }
 
+    p.preventRestart()
+
+    // The process is looked up before the stop command runs, so that what
+    // it started is still checked for when it exits straight away.
+    cmd, exited := p.running()
+
     if p.StopCmd != "" {
-        err := p.execute("task", p.StopCmd, "stop")
+        err := p.execute(ctx, "task", p.StopCmd, "stop", time.Duration(p.Timeouts.Stop))
         if err != nil {
+            if cmd != nil && ctx.Err() != nil {
+                p.forceKill(cmd, exited)
+            }
+
             return err
         }
     }
 
-    if p.process != nil && p.process.Process != nil {
-        fmt.Println("Killing process")
-        err := p.process.Process.Kill()
-        if err != nil {
-            return err
-        }
+    if cmd == nil {
+        return nil
+    }
+
+    if p.StopCmd == "" {
+        return p.kill(ctx, cmd, exited)
+    }
+
+    // The stop command is given the stop timeout to take effect, and the
+    // process is killed if it is still running after that.
+    timeout := p.stopTimeout()
+
+    select {
+    case <-exited:
+    case <-ctx.Done():
+        fmt.Printf("Killing '%s'\n", p.Name)
+
+        return p.forceKill(cmd, exited)
+    case <-time.After(timeout):
+        fmt.Printf("'%s' didn't exit within %s of the stop command, killing it\n", p.Name, timeout)
+
+        return p.forceKill(cmd, exited)
+    }
+
+    // The stop command might only stop the process itself, so whatever it
+    // started is killed if it doesn't follow it.
+    if processGroupExited(cmd, stopVerifyTimeout) {
+        return nil
+    }
+
+    fmt.Printf("Processes started by '%s' are still running after it exited, killing them\n", p.Name)
+
+    return p.forceKill(cmd, exited)
+}
+
+// preventRestart keeps the restart policy and the health checks from starting
+// the process again until something else starts it.
+func (p *Process) preventRestart() {
+    p.mu.Lock()
+    p.stopping = true
+    p.cancelRestart()
+    p.mu.Unlock()
+}
+
+// isStopping returns whether the process was stopped and hasn't been started
+// again since, or is being torn down.
+func (p *Process) isStopping() bool {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    return p.stopping || p.tearingDown
+}
+
+func (p *Process) stopTimeout() time.Duration {
+    if p.StopTimeout > 0 {
+        return time.Duration(p.StopTimeout)
+    }
+
+    return defaultStopTimeout
+}
+
+// kill stops the background process along with every process it started.
+// They are sent the stop signal, then killed if they haven't exited within
+// the stop timeout. The process only counts as exited once it has been
+// reaped, which lasts until its output has been copied, so nothing that
+// shares the output is left running either.
+func (p *Process) kill(ctx context.Context, cmd *exec.Cmd, exited chan struct{}) error {
+    if ctx.Err() != nil {
+        fmt.Printf("Killing '%s'\n", p.Name)
+
+        return p.forceKill(cmd, exited)
+    }
+
+    sig := syscall.SIGTERM
+    if p.StopSignal != 0 {
+        sig = syscall.Signal(p.StopSignal)
     }
 
-    p.process = nil
+    timeout := p.stopTimeout()
+
+    fmt.Printf("Stopping '%s' with %s\n", p.Name, Signal(sig))
+    signalProcessGroup(cmd, sig)
+
+    select {
+    case <-exited:
+        fmt.Printf("'%s' exited cleanly\n", p.Name)
+    case <-ctx.Done():
+        fmt.Printf("Killing '%s'\n", p.Name)
+
+        return p.forceKill(cmd, exited)
+    case <-time.After(timeout):
+        fmt.Printf("'%s' didn't exit within %s, killing it\n", p.Name, timeout)
+
+        return p.forceKill(cmd, exited)
+    }
+
+    if !processGroupExited(cmd, stopVerifyTimeout) {
+        return fmt.Errorf("processes started by '%s' are still running after it was stopped", p.StartCmd)
+    }
+
+    return nil
+}
+
+// forceKill kills every process in the background process's group, then
+// checks that none of them survived.
+func (p *Process) forceKill(cmd *exec.Cmd, exited chan struct{}) error {
+    killProcessGroup(cmd)
+
+    select {
+    case <-exited:
+    case <-time.After(stopVerifyTimeout):
+        return fmt.Errorf("processes started by '%s' are still running after it was killed", p.StartCmd)
+    }
+
+    if !processGroupExited(cmd, stopVerifyTimeout) {
+        return fmt.Errorf("processes started by '%s' are still running after it was killed", p.StartCmd)
+    }
 
     return nil
 }
 
 func (p *Process) Cleanup() error {
+    return p.cleanup(context.Background())
+}
+
+func (p *Process) cleanup(ctx context.Context) error {
     if p.execContext == nil {
         p.execContext = exec.Command
     }
 
     if p.CleanupCmd != "" {
-        err := p.execute("task", p.CleanupCmd, "cleanup")
+        err := p.execute(ctx, "task", p.CleanupCmd, "cleanup", time.Duration(p.Timeouts.Cleanup))
         if err != nil {
             return err
         }
@@ -85,13 +347,105 @@ func (p *Process) Cleanup() error {
     return nil
 }
 
+// Restart stops the process and starts it again, or runs its restart command
+// if it has one. It waits for any other restart of the process to finish
+// first.
 func (p *Process) Restart(runCleanup bool) error {
+    ctx, done := p.beginRestart()
+    defer done()
+
+    return p.restart(ctx, runCleanup)
+}
+
+// restartSlot returns the slot that restarts and teardown take turns to hold.
+func (p *Process) restartSlot() chan struct{} {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    if p.restarting == nil {
+        p.restarting = make(chan struct{}, 1)
+    }
+
+    return p.restarting
+}
+
+// beginRestart waits for any other restart or teardown of the process to
+// finish, then returns the context to restart it with and the function that
+// ends the restart. Teardown cancels the context once it can't wait any
+// longer.
+func (p *Process) beginRestart() (context.Context, func()) {
+    slot := p.restartSlot()
+    slot <- struct{}{}
+
+    ctx, cancel := context.WithCancel(context.Background())
+
+    p.mu.Lock()
+    if p.abortRestart {
+        cancel()
+    }
+    p.restartCancel = cancel
+    p.mu.Unlock()
+
+    return ctx, func() {
+        p.mu.Lock()
+        p.restartCancel = nil
+        p.mu.Unlock()
+
+        cancel()
+        <-slot
+    }
+}
+
+// beginTeardown waits for a restart of the process that is already running
+// to finish, so that it can't launch the process again once it has been
+// stopped, and returns the function that ends the teardown. Once the context
+// is done, the restart is cancelled instead, which stops it straight away.
+func (p *Process) beginTeardown(ctx context.Context) func() {
+    slot := p.restartSlot()
+
+    p.mu.Lock()
+    p.tearingDown = true
+    p.mu.Unlock()
+
+    select {
+    case slot <- struct{}{}:
+    case <-ctx.Done():
+        p.mu.Lock()
+        p.abortRestart = true
+        if p.restartCancel != nil {
+            p.restartCancel()
+        }
+        p.mu.Unlock()
+
+        slot <- struct{}{}
+    }
+
+    return func() {
+        p.mu.Lock()
+        p.tearingDown = false
+        p.abortRestart = false
+        p.mu.Unlock()
+
+        <-slot
+    }
+}
+
+// restart restarts the process, stopping as soon as the context is done. It
+// must be called between beginRestart and the end of the restart.
+func (p *Process) restart(ctx context.Context, runCleanup bool) error {
     if p.execContext == nil {
         p.execContext = exec.Command
     }
 
+    // A restart is the way out of a crash loop, so it starts the restart
+    // policy over.
+    p.mu.Lock()
+    p.failed = false
+    p.restarts = nil
+    p.mu.Unlock()
+
     if p.RestartCmd != "" {
-        err := p.execute("task", p.RestartCmd, "restart")
+        err := p.execute(ctx, "task", p.RestartCmd, "restart", time.Duration(p.Timeouts.Restart))
         if err != nil {
             return err
         }
@@ -99,19 +453,23 @@ func (p *Process) Restart(runCleanup bool) error {
         return nil
     }
 
-    err := p.Stop()
+    err := p.stop(ctx)
     if err != nil {
         return err
     }
 
     if runCleanup {
-        err = p.Cleanup()
+        err = p.cleanup(ctx)
         if err != nil {
             return err
         }
     }
 
-    err = p.Start()
+    if ctx.Err() != nil {
+        return ctx.Err()
+    }
+
+    err = p.startWith(ctx, nil)
     if err != nil {
         return err
     }
@@ -119,9 +477,14 @@ func (p *Process) Restart(runCleanup bool) error {
     return nil
 }
 
-func (p *Process) execute(commandType, command, commandUse string) error {
+// execute runs a command of the process, copying its output to the outputs
+// as well as stdout. The outputs come first so that they still see the
+// output if writing to stdout fails. A task run with a context that can
+// be cancelled or a timeout is put in its own process group, so that every
+// process the command started is stopped with it and not just the shell.
+func (p *Process) execute(ctx context.Context, commandType, command, commandUse string, timeout time.Duration, outputs ...io.Writer) error {
     var stdBuffer bytes.Buffer
-    mw := io.MultiWriter(os.Stdout, &stdBuffer)
+    mw := io.MultiWriter(append(outputs, os.Stdout, &stdBuffer)...)
 
     args := []string{"-c", command}
     cmd := p.execContext("bash", args...)
@@ -129,7 +492,19 @@ func (p *Process) execute(commandType, command, commandUse string) error {
     cmd.Stdout = mw
     cmd.Stderr = mw
 
-    p.process = cmd
+    if !p.Environment.empty() {
+        base := cmd.Env
+        if base == nil {
+            base = os.Environ()
+        }
+
+        env, err := p.Environment.environ(base)
+        if err != nil {
+            return err
+        }
+
+        cmd.Env = env
+    }
 
     var nameInfo string
     if p.Name != "" {
@@ -140,18 +515,40 @@ func (p *Process) execute(commandType, command, commandUse string) error {
 
     switch commandType {
     case "background":
-        err := p.process.Start()
+        setProcessGroup(cmd)
+
+        err := cmd.Start()
         if err != nil {
             return err
         }
+
+        exited := make(chan struct{})
+
+        p.mu.Lock()
+        p.process = cmd
+        p.exited = exited
+        p.mu.Unlock()
+
+        go p.reap(cmd, time.Now(), exited)
+
+        if !p.HealthCheck.Probe.empty() {
+            go p.monitor(exited)
+        }
     case "task":
-        //todo: timeout
-        err := p.process.Run()
+        p.mu.Lock()
+        p.commands++
+        p.mu.Unlock()
+
+        err := p.wait(ctx, cmd, command, timeout)
+
+        p.mu.Lock()
+        p.commands--
+        p.idleSince = time.Now()
+        p.mu.Unlock()
+
         if err != nil {
             return err
         }
-
-        p.process = nil
     default:
         return fmt.Errorf("valid process types are: 'background' and 'task'")
     }
@@ -160,3 +557,61 @@ func (p *Process) execute(commandType, command, commandUse string) error {
 
     return nil
 }
+
+func (p *Process) wait(ctx context.Context, cmd *exec.Cmd, command string, timeout time.Duration) error {
+    if ctx.Done() == nil && timeout <= 0 {
+        return cmd.Run()
+    }
+
+    setProcessGroup(cmd)
+
+    err := cmd.Start()
+    if err != nil {
+        return err
+    }
+
+    done := make(chan error, 1)
+    go func() {
+        done <- cmd.Wait()
+    }()
+
+    var expired <-chan time.Time
+    if timeout > 0 {
+        timer := time.NewTimer(timeout)
+        defer timer.Stop()
+
+        expired = timer.C
+    }
+
+    select {
+    case err = <-done:
+        return err
+    case <-ctx.Done():
+        killProcessGroup(cmd)
+        <-done
+
+        return ctx.Err()
+    case <-expired:
+    }
+
+    // The command is asked to stop first so that it can clean up after
+    // itself, then killed if it doesn't.
+    fmt.Printf("Timed out after %s, stopping: '%s'\n", timeout, command)
+    signalProcessGroup(cmd, syscall.SIGTERM)
+
+    grace := time.NewTimer(timeoutGracePeriod)
+    defer grace.Stop()
+
+    select {
+    case <-done:
+    case <-grace.C:
+        fmt.Printf("Killing after it didn't stop within %s: '%s'\n", timeoutGracePeriod, command)
+        killProcessGroup(cmd)
+        <-done
+    case <-ctx.Done():
+        killProcessGroup(cmd)
+        <-done
+    }
+
+    return &TimeoutError{Command: command, Timeout: timeout}
+}
@@ -0,0 +1,69 @@
+// +build !windows
+
+package runners
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// signals are the signals a process can be configured to stop with.
+var signals = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+	"TERM": syscall.SIGTERM,
+}
+
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// killProcessGroup kills the process group led by the command, which holds
+// every process it started that hasn't moved to a group of its own.
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// signalProcessGroup sends the signal to every process in the command's
+// group.
+func signalProcessGroup(cmd *exec.Cmd, sig syscall.Signal) {
+	if cmd.Process == nil {
+		return
+	}
+
+	syscall.Kill(-cmd.Process.Pid, sig)
+}
+
+// processGroupExited waits for every process in the command's group to exit,
+// returning false if any of them are still running after the timeout.
+func processGroupExited(cmd *exec.Cmd, timeout time.Duration) bool {
+	if cmd.Process == nil {
+		return true
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		if syscall.Kill(-cmd.Process.Pid, 0) == syscall.ESRCH {
+			return true
+		}
+
+		if time.Now().After(deadline) {
+			return false
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -1,10 +1,20 @@
 package runners_test
 
 import (
+    "errors"
     "io/ioutil"
+    "net"
+    "net/http"
+    "net/http/httptest"
     "os"
     "os/exec"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "sync/atomic"
+    "syscall"
     "testing"
+    "time"
 
     "github.com/iplay88keys/watchtower/pkg/runners"
 
@@ -30,6 +40,8 @@ var _ = Describe("Process", func() {
         err = proc.Start()
         Expect(err).ToNot(HaveOccurred())
 
+        Eventually(proc.Running, 10).Should(BeFalse())
+
         err = w.Close()
         Expect(err).ToNot(HaveOccurred())
 
@@ -38,7 +50,43 @@ var _ = Describe("Process", func() {
 
         os.Stdout = stdout
 
-        Eventually(string(out)).Should(Equal("Running 'test' start command: 'start_command'\n\n"))
+        Expect(string(out)).To(MatchRegexp("^Running 'test' start command: 'start_command'\n\n'test' exited with code 0 after .+\n$"))
+    })
+
+    It("records how a background process exited", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        proc := runners.Process{
+            Name:     "test",
+            Type:     "background",
+            StartCmd: "exit 3",
+        }
+
+        Expect(proc.LastExit()).To(BeNil())
+
+        err = proc.Start()
+        Expect(err).ToNot(HaveOccurred())
+
+        Eventually(proc.Running, 10).Should(BeFalse())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(MatchRegexp("'test' exited with code 3 after .+\n$"))
+
+        status := proc.LastExit()
+        Expect(status).ToNot(BeNil())
+        Expect(status.Code).To(Equal(3))
+        Expect(status.Signal).To(BeZero())
+        Expect(status.Runtime).To(BeNumerically(">", 0))
     })
 
     It("only starts a background process once", func() {
@@ -53,7 +101,7 @@ var _ = Describe("Process", func() {
             StartCmd: "start_command",
         }
 
-        proc.UpdateExecContext(fakeExecCommandSuccess)
+        proc.UpdateExecContext(fakeExecCommandRunning)
 
         err = proc.Start()
         Expect(err).ToNot(HaveOccurred())
@@ -61,6 +109,9 @@ var _ = Describe("Process", func() {
         err = proc.Start()
         Expect(err).ToNot(HaveOccurred())
 
+        err = proc.Stop()
+        Expect(err).ToNot(HaveOccurred())
+
         err = w.Close()
         Expect(err).ToNot(HaveOccurred())
 
@@ -69,7 +120,7 @@ var _ = Describe("Process", func() {
 
         os.Stdout = stdout
 
-        Eventually(string(out)).Should(Equal("Running 'test' start command: 'start_command'\n\nProcess is already running\n"))
+        Expect(string(out)).To(MatchRegexp("^Running 'test' start command: 'start_command'\n\nProcess is already running\nStopping 'test' with SIGTERM\n'test' exited with signal SIGTERM after .+\n'test' exited cleanly\n$"))
     })
 
     It("starts a task using the start command", func() {
@@ -100,6 +151,46 @@ var _ = Describe("Process", func() {
         Eventually(string(out)).Should(Equal("Running 'test' start command: 'start_command'\n\n"))
     })
 
+    It("reports a task as active while it runs", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        proc := runners.Process{
+            Name:     "test",
+            Type:     "task",
+            StartCmd: "sleep 0.5",
+        }
+
+        active, _ := proc.Active()
+        Expect(active).To(BeFalse())
+
+        done := make(chan error, 1)
+        go func() {
+            done <- proc.Start()
+        }()
+
+        Eventually(func() bool {
+            active, _ := proc.Active()
+            return active
+        }, 10).Should(BeTrue())
+
+        Eventually(done, 10).Should(Receive(BeNil()))
+
+        active, stopped := proc.Active()
+        Expect(active).To(BeFalse())
+        Expect(stopped).To(BeTemporally("~", time.Now(), time.Second))
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        _, err = ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+    })
+
     It("stops a process using the stop command", func() {
         stdout := os.Stdout
         r, w, err := os.Pipe()
@@ -118,9 +209,257 @@ var _ = Describe("Process", func() {
         err = proc.Start()
         Expect(err).ToNot(HaveOccurred())
 
+        Eventually(proc.Running, 10).Should(BeFalse())
+
+        err = proc.Stop()
+        Expect(err).ToNot(HaveOccurred())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(MatchRegexp("^Running 'test' start command: 'start_command'\n\n'test' exited with code 0 after .+\nRunning 'test' stop command: 'stop_command'\n\n$"))
+    })
+
+    It("stops a process with the stop signal", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        proc := runners.Process{
+            Name:     "test",
+            Type:     "background",
+            StartCmd: "sleep 30",
+        }
+
+        err = proc.Start()
+        Expect(err).ToNot(HaveOccurred())
+
+        err = proc.Stop()
+        Expect(err).ToNot(HaveOccurred())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(MatchRegexp("^Running 'test' start command: 'sleep 30'\n\nStopping 'test' with SIGTERM\n'test' exited with signal SIGTERM after .+\n'test' exited cleanly\n$"))
+    })
+
+    It("kills a process that doesn't exit within the stop timeout", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        proc := runners.Process{
+            Name:        "test",
+            Type:        "background",
+            StartCmd:    "trap '' TERM; sleep 30 & wait",
+            StopSignal:  runners.Signal(syscall.SIGTERM),
+            StopTimeout: runners.Duration(200 * time.Millisecond),
+        }
+
+        err = proc.Start()
+        Expect(err).ToNot(HaveOccurred())
+
+        // Gives the shell time to ignore the signal before it is sent
+        time.Sleep(200 * time.Millisecond)
+
+        start := time.Now()
+        err = proc.Stop()
+        Expect(err).ToNot(HaveOccurred())
+        Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(MatchRegexp("Stopping 'test' with SIGTERM\n'test' didn't exit within 200ms, killing it\n'test' exited with signal SIGKILL after .+\n$"))
+    })
+
+    It("stops every process a background process started", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        dir, err := ioutil.TempDir("", "process")
+        Expect(err).ToNot(HaveOccurred())
+        defer os.RemoveAll(dir)
+
+        pidFile := filepath.Join(dir, "pid")
+
+        proc := runners.Process{
+            Name:     "test",
+            Type:     "background",
+            StartCmd: "(sleep 30 & echo $! > " + pidFile + "; wait) & wait",
+        }
+
+        err = proc.Start()
+        Expect(err).ToNot(HaveOccurred())
+
+        var contents []byte
+        Eventually(func() string {
+            contents, _ = ioutil.ReadFile(pidFile)
+            return string(contents)
+        }, 10).Should(HaveSuffix("\n"))
+
+        pid, err := strconv.Atoi(strings.TrimSpace(string(contents)))
+        Expect(err).ToNot(HaveOccurred())
+
+        start := time.Now()
+        err = proc.Stop()
+        Expect(err).ToNot(HaveOccurred())
+        Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        _, err = ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Eventually(func() error {
+            sleep, err := os.FindProcess(pid)
+            if err != nil {
+                return err
+            }
+
+            return sleep.Signal(syscall.Signal(0))
+        }, 10).Should(HaveOccurred())
+    })
+
+    It("kills the processes a background process started once the stop command has stopped it", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        dir, err := ioutil.TempDir("", "process")
+        Expect(err).ToNot(HaveOccurred())
+        defer os.RemoveAll(dir)
+
+        leaderFile := filepath.Join(dir, "leader")
+        pidFile := filepath.Join(dir, "pid")
+
+        proc := runners.Process{
+            Name:     "test",
+            Type:     "background",
+            StartCmd: "echo $$ > " + leaderFile + "; sleep 30 > /dev/null 2>&1 & echo $! > " + pidFile + "; wait",
+            StopCmd:  "kill $(cat " + leaderFile + ")",
+        }
+
+        err = proc.Start()
+        Expect(err).ToNot(HaveOccurred())
+
+        var contents []byte
+        Eventually(func() string {
+            contents, _ = ioutil.ReadFile(pidFile)
+            return string(contents)
+        }, 10).Should(HaveSuffix("\n"))
+
+        pid, err := strconv.Atoi(strings.TrimSpace(string(contents)))
+        Expect(err).ToNot(HaveOccurred())
+
+        err = proc.Stop()
+        Expect(err).ToNot(HaveOccurred())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(ContainSubstring("Processes started by 'test' are still running after it exited, killing them\n"))
+
+        Eventually(func() error {
+            sleep, err := os.FindProcess(pid)
+            if err != nil {
+                return err
+            }
+
+            return sleep.Signal(syscall.Signal(0))
+        }, 10).Should(HaveOccurred())
+    })
+
+    It("restarts a crashed process until it is crash looping", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        proc := runners.Process{
+            Name:           "test",
+            Type:           "background",
+            StartCmd:       "exit 1",
+            RestartPolicy:  runners.RestartOnFailure,
+            RestartBackoff: runners.Duration(10 * time.Millisecond),
+            MaxRestarts:    2,
+        }
+
+        err = proc.Start()
+        Expect(err).ToNot(HaveOccurred())
+
+        Eventually(proc.Failed, 10).Should(BeTrue())
+        Expect(proc.Running()).To(BeFalse())
+
+        err = proc.Restart(false)
+        Expect(err).ToNot(HaveOccurred())
+
+        Eventually(proc.Failed, 10).Should(BeTrue())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(strings.Count(string(out), "Running 'test' start command: 'exit 1'")).To(Equal(6))
+        Expect(strings.Count(string(out), "Restarting 'test' in 10ms\n")).To(Equal(2))
+        Expect(strings.Count(string(out), "Restarting 'test' in 20ms\n")).To(Equal(2))
+        Expect(strings.Count(string(out), "'test' is crash looping, it won't be restarted again until a trigger restarts it\n")).To(Equal(2))
+    })
+
+    It("doesn't restart a process that was stopped", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        proc := runners.Process{
+            Name:           "test",
+            Type:           "background",
+            StartCmd:       "sleep 30",
+            RestartPolicy:  runners.RestartAlways,
+            RestartBackoff: runners.Duration(10 * time.Millisecond),
+        }
+
+        err = proc.Start()
+        Expect(err).ToNot(HaveOccurred())
+
         err = proc.Stop()
         Expect(err).ToNot(HaveOccurred())
 
+        Consistently(proc.Running, "200ms").Should(BeFalse())
+
         err = w.Close()
         Expect(err).ToNot(HaveOccurred())
 
@@ -129,10 +468,10 @@ var _ = Describe("Process", func() {
 
         os.Stdout = stdout
 
-        Eventually(string(out)).Should(Equal("Running 'test' start command: 'start_command'\n\nRunning 'test' stop command: 'stop_command'\n\n"))
+        Expect(string(out)).ToNot(ContainSubstring("Restarting 'test'"))
     })
 
-    It("stops a process by killing it", func() {
+    It("waits for a background process to log that it is ready", func() {
         stdout := os.Stdout
         r, w, err := os.Pipe()
         Expect(err).ToNot(HaveOccurred())
@@ -141,11 +480,16 @@ var _ = Describe("Process", func() {
         proc := runners.Process{
             Name:     "test",
             Type:     "background",
-            StartCmd: "watch ls",
+            StartCmd: "sleep 0.3; echo 'listening on :8080'; sleep 30",
+            Ready: runners.Ready{
+                Log: "listening on :\\d+",
+            },
         }
 
+        start := time.Now()
         err = proc.Start()
         Expect(err).ToNot(HaveOccurred())
+        Expect(time.Since(start)).To(BeNumerically(">=", 300*time.Millisecond))
 
         err = proc.Stop()
         Expect(err).ToNot(HaveOccurred())
@@ -158,7 +502,198 @@ var _ = Describe("Process", func() {
 
         os.Stdout = stdout
 
-        Eventually(string(out)).Should(Equal("Running 'test' start command: 'watch ls'\n\nKilling process\n"))
+        Expect(string(out)).To(ContainSubstring("listening on :8080\n'test' is ready\n"))
+    })
+
+    It("waits for a background process to respond over http", func() {
+        var requests int32
+        server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            if atomic.AddInt32(&requests, 1) < 3 {
+                w.WriteHeader(http.StatusServiceUnavailable)
+                return
+            }
+
+            w.WriteHeader(http.StatusNoContent)
+        }))
+        defer server.Close()
+
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        proc := runners.Process{
+            Name:     "test",
+            Type:     "background",
+            StartCmd: "sleep 30",
+            Ready: runners.Ready{
+                Probe: runners.Probe{
+                    HTTP:   server.URL,
+                    Status: http.StatusNoContent,
+                },
+            },
+        }
+
+        err = proc.Start()
+        Expect(err).ToNot(HaveOccurred())
+        Expect(atomic.LoadInt32(&requests)).To(Equal(int32(3)))
+
+        err = proc.Stop()
+        Expect(err).ToNot(HaveOccurred())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        _, err = ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+    })
+
+    It("waits for a ready command that takes longer than the check interval", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        proc := runners.Process{
+            Name:     "test",
+            Type:     "background",
+            StartCmd: "sleep 30",
+            Ready: runners.Ready{
+                Probe: runners.Probe{
+                    Command: "sleep 0.5",
+                },
+                Timeout: runners.Duration(5 * time.Second),
+            },
+        }
+
+        err = proc.Start()
+        Expect(err).ToNot(HaveOccurred())
+
+        err = proc.Stop()
+        Expect(err).ToNot(HaveOccurred())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(ContainSubstring("'test' is ready\n"))
+    })
+
+    It("runs the ready command with the process's environment", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        proc := runners.Process{
+            Name:     "test",
+            Type:     "background",
+            StartCmd: "sleep 30",
+            Environment: runners.Environment{
+                Env: map[string]string{"READY_PORT": "8080"},
+            },
+            Ready: runners.Ready{
+                Probe: runners.Probe{
+                    Command: `test "$READY_PORT" = 8080`,
+                },
+                Timeout: runners.Duration(time.Second),
+            },
+        }
+
+        err = proc.Start()
+        Expect(err).ToNot(HaveOccurred())
+
+        err = proc.Stop()
+        Expect(err).ToNot(HaveOccurred())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(ContainSubstring("'test' is ready\n"))
+    })
+
+    It("returns an error if a background process isn't ready in time", func() {
+        listener, err := net.Listen("tcp", "127.0.0.1:0")
+        Expect(err).ToNot(HaveOccurred())
+
+        addr := listener.Addr().String()
+        listener.Close()
+
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        proc := runners.Process{
+            Name:     "test",
+            Type:     "background",
+            StartCmd: "sleep 30",
+            Ready: runners.Ready{
+                Probe: runners.Probe{
+                    TCP: addr,
+                },
+                Timeout: runners.Duration(300 * time.Millisecond),
+            },
+        }
+
+        err = proc.Start()
+        Expect(err).To(MatchError(HavePrefix("'test' wasn't ready within 300ms: couldn't connect to '" + addr + "'")))
+        Expect(proc.Running()).To(BeFalse())
+
+        err = proc.Start()
+        Expect(err).To(MatchError(HavePrefix("'test' wasn't ready within 300ms")))
+        Expect(proc.Running()).To(BeFalse())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(strings.Count(string(out), "Killing 'test' as it isn't ready\n")).To(Equal(2))
+        Expect(string(out)).ToNot(ContainSubstring("Process is already running"))
+    })
+
+    It("returns an error if a background process exits before it is ready", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        proc := runners.Process{
+            Name:     "test",
+            Type:     "background",
+            StartCmd: "sleep 0.1; exit 1",
+            Ready: runners.Ready{
+                Probe: runners.Probe{
+                    Command: "false",
+                },
+            },
+        }
+
+        err = proc.Start()
+        Expect(err).To(MatchError("'test' exited before it was ready: 'false' failed: exit status 1"))
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        _, err = ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
     })
 
     It("restarts a process by stopping and starting it", func() {
@@ -180,6 +715,8 @@ var _ = Describe("Process", func() {
         err = proc.Restart(false)
         Expect(err).ToNot(HaveOccurred())
 
+        Eventually(proc.Running, 10).Should(BeFalse())
+
         err = w.Close()
         Expect(err).ToNot(HaveOccurred())
 
@@ -188,7 +725,7 @@ var _ = Describe("Process", func() {
 
         os.Stdout = stdout
 
-        Eventually(string(out)).Should(Equal("Running 'test' stop command: 'stop_command'\n\nRunning 'test' start command: 'start_command'\n\n"))
+        Expect(string(out)).To(MatchRegexp("^Running 'test' stop command: 'stop_command'\n\nRunning 'test' start command: 'start_command'\n\n'test' exited with code 0 after .+\n$"))
     })
 
     It("restarts a process and runs the cleanup command", func() {
@@ -210,6 +747,8 @@ var _ = Describe("Process", func() {
         err = proc.Restart(true)
         Expect(err).ToNot(HaveOccurred())
 
+        Eventually(proc.Running, 10).Should(BeFalse())
+
         err = w.Close()
         Expect(err).ToNot(HaveOccurred())
 
@@ -218,7 +757,7 @@ var _ = Describe("Process", func() {
 
         os.Stdout = stdout
 
-        Eventually(string(out)).Should(Equal("Running 'test' stop command: 'stop_command'\n\nRunning 'test' cleanup command: 'cleanup_command'\n\nRunning 'test' start command: 'start_command'\n\n"))
+        Expect(string(out)).To(MatchRegexp("^Running 'test' stop command: 'stop_command'\n\nRunning 'test' cleanup command: 'cleanup_command'\n\nRunning 'test' start command: 'start_command'\n\n'test' exited with code 0 after .+\n$"))
     })
 
     It("restarts a process using the restart command", func() {
@@ -250,6 +789,46 @@ var _ = Describe("Process", func() {
         Eventually(string(out)).Should(Equal("Running 'test' restart command: 'restart_command'\n\n"))
     })
 
+    It("stops a stop command that runs past its timeout", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        proc := runners.Process{
+            Name:     "test",
+            Type:     "background",
+            StartCmd: "sleep 30",
+            StopCmd:  "sleep 5",
+            Timeouts: runners.Timeouts{
+                Stop: runners.Duration(200 * time.Millisecond),
+            },
+        }
+
+        err = proc.Start()
+        Expect(err).ToNot(HaveOccurred())
+
+        start := time.Now()
+        err = proc.Stop()
+        Expect(time.Since(start)).To(BeNumerically("<", 2*time.Second))
+
+        var timeoutErr *runners.TimeoutError
+        Expect(errors.As(err, &timeoutErr)).To(BeTrue())
+        Expect(timeoutErr.Command).To(Equal("sleep 5"))
+        Expect(timeoutErr.Timeout).To(Equal(200 * time.Millisecond))
+        Expect(err).To(MatchError("'sleep 5' timed out after 200ms"))
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(ContainSubstring("Timed out after 200ms, stopping: 'sleep 5'\n"))
+    })
+
     It("returns an error if the process type is invalid", func() {
         osStdout := os.Stdout
         osStderr := os.Stderr
@@ -283,6 +862,27 @@ func TestShellProcessSuccess(t *testing.T) {
     os.Exit(0)
 }
 
+func TestShellProcessRunning(t *testing.T) {
+    RegisterTestingT(t)
+    if os.Getenv("GO_TEST_PROCESS") != "1" {
+        return
+    }
+
+    time.Sleep(30 * time.Second)
+    os.Exit(0)
+}
+
+func fakeExecCommandRunning(command string, args ...string) *exec.Cmd {
+    cs := []string{"-test.run=TestShellProcessRunning", "--", command}
+    cs = append(cs, args...)
+
+    cmd := exec.Command(os.Args[0], cs...)
+
+    cmd.Env = []string{"GO_TEST_PROCESS=1"}
+
+    return cmd
+}
+
 func fakeExecCommandSuccess(command string, args ...string) *exec.Cmd {
     cs := []string{"-test.run=TestShellProcessSuccess", "--", command}
     cs = append(cs, args...)
@@ -0,0 +1,43 @@
+package runners
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// signals are the signals a process can be configured to stop with. Windows
+// can't deliver any of them, so every one kills the process.
+var signals = map[string]syscall.Signal{
+	"INT":  syscall.SIGINT,
+	"KILL": syscall.SIGKILL,
+	"TERM": syscall.SIGTERM,
+}
+
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills the command and every process it started. Windows
+// has no process groups, so taskkill is used to walk the process tree, with
+// the command itself killed if that fails.
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+
+	err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+	if err != nil {
+		cmd.Process.Kill()
+	}
+}
+
+// signalProcessGroup kills the command, as Windows can't send it a signal.
+func signalProcessGroup(cmd *exec.Cmd, sig syscall.Signal) {
+	killProcessGroup(cmd)
+}
+
+// processGroupExited always reports the processes as exited, as Windows has
+// no process group to check.
+func processGroupExited(cmd *exec.Cmd, timeout time.Duration) bool {
+	return true
+}
@@ -0,0 +1,132 @@
+package runners
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	defaultReadyTimeout = 30 * time.Second
+	readyInterval       = 250 * time.Millisecond
+)
+
+// Ready decides when a background process has finished starting. Start and
+// Restart wait until every check that is set passes.
+type Ready struct {
+	Probe
+
+	// Log is a pattern that a line of the process's output has to match.
+	Log string `json:"log"`
+
+	// Timeout is how long to wait for the process to be ready before
+	// failing. Defaults to 30 seconds.
+	Timeout Duration `json:"timeout"`
+}
+
+func (r Ready) empty() bool {
+	return r.Probe.empty() && r.Log == ""
+}
+
+// waitReady waits for the process to pass its ready checks, failing if it
+// exits, the timeout passes or the context is done first.
+func (p *Process) waitReady(ctx context.Context, logs *logMatcher, exited chan struct{}) error {
+	timeout := defaultReadyTimeout
+	if p.Ready.Timeout > 0 {
+		timeout = time.Duration(p.Ready.Timeout)
+	}
+
+	end := time.Now().Add(timeout)
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		err := p.checkReady(ctx, logs, time.Until(end))
+		if err == nil {
+			fmt.Printf("'%s' is ready\n", p.Name)
+
+			return nil
+		}
+
+		select {
+		case <-exited:
+			return fmt.Errorf("'%s' exited before it was ready: %s", p.Name, err)
+		case <-deadline.C:
+			return fmt.Errorf("'%s' wasn't ready within %s: %s", p.Name, timeout, err)
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(readyInterval):
+		}
+	}
+}
+
+// checkReady runs the ready checks, giving the probe whatever time is left
+// before the deadline so that a slow probe can still pass.
+func (p *Process) checkReady(ctx context.Context, logs *logMatcher, left time.Duration) error {
+	if logs != nil && !logs.matched() {
+		return fmt.Errorf("no output has matched '%s'", p.Ready.Log)
+	}
+
+	if left <= 0 {
+		left = readyInterval
+	}
+
+	return p.checkProbe(ctx, p.Ready.Probe, left)
+}
+
+// logMatcher is a writer that looks for a line of output matching a pattern.
+type logMatcher struct {
+	pattern *regexp.Regexp
+
+	mu    sync.Mutex
+	line  []byte
+	found bool
+}
+
+func newLogMatcher(pattern string) (*logMatcher, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ready log pattern '%s': %s", pattern, err)
+	}
+
+	return &logMatcher{pattern: re}, nil
+}
+
+func (l *logMatcher) Write(data []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.found {
+		return len(data), nil
+	}
+
+	l.line = append(l.line, data...)
+
+	for {
+		end := bytes.IndexByte(l.line, '\n')
+		if end < 0 {
+			break
+		}
+
+		if l.pattern.Match(l.line[:end]) {
+			l.found = true
+			l.line = nil
+
+			return len(data), nil
+		}
+
+		l.line = l.line[end+1:]
+	}
+
+	return len(data), nil
+}
+
+func (l *logMatcher) matched() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.found || l.pattern.Match(l.line)
+}
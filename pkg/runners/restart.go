@@ -1,28 +1,71 @@
 package runners
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 type Restart struct {
-	Restart    string `json:"restart"`
-	RunCleanup bool   `json:"runCleanup"`
+	Restart           string `json:"restart"`
+	RunCleanup        bool   `json:"runCleanup"`
+	RestartDependents bool   `json:"restartDependents"`
 
-	process Restartable
+	process    Restartable
+	dependents []Dependent
 }
 
 type Restartable interface {
 	Restart(runCleanup bool) error
 }
 
+// Dependent is a process that depends on the restarted process.
+type Dependent interface {
+	Start() error
+	Stop() error
+}
+
 func (r *Restart) Setup(process Restartable) {
 	r.process = process
 }
 
-func (r *Restart) Execute(triggeringFileName string) error {
+// SetupDependents sets the processes that depend on the process, in the
+// order they are started. When RestartDependents is set they are stopped
+// before the process is restarted and started again after it.
+func (r *Restart) SetupDependents(dependents ...Dependent) {
+	r.dependents = dependents
+}
+
+// Execute restarts the process. A restart isn't interrupted once it has
+// begun, so that the process isn't left stopped.
+func (r *Restart) Execute(ctx context.Context, change Change) error {
 	fmt.Println("Restarting process:", r.Restart)
+
+	if r.RestartDependents {
+		for i := len(r.dependents) - 1; i >= 0; i-- {
+			err := r.dependents[i].Stop()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	err := r.process.Restart(r.RunCleanup)
 	if err != nil {
 		return err
 	}
 
+	if r.RestartDependents {
+		for _, dependent := range r.dependents {
+			err = dependent.Start()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
+
+func (r *Restart) String() string {
+	return fmt.Sprintf("restart '%s'", r.Restart)
+}
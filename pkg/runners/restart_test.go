@@ -1,6 +1,7 @@
 package runners_test
 
 import (
+	"context"
 	"os"
 
 	"github.com/iplay88keys/watchtower/pkg/runners"
@@ -33,19 +34,74 @@ var _ = Describe("Restart", func() {
 
 		restartRunner := runners.Restart{}
 		restartRunner.Setup(&proc)
-		err := restartRunner.Execute("")
+		err := restartRunner.Execute(context.Background(), runners.Change{})
 		Expect(err).ToNot(HaveOccurred())
 
 		Expect(proc.called).To(BeTrue())
 	})
+
+	It("stops the dependents before restarting a process and starts them after", func() {
+		var calls []string
+
+		proc := restartMock{calls: &calls}
+
+		restartRunner := runners.Restart{RestartDependents: true}
+		restartRunner.Setup(&proc)
+		restartRunner.SetupDependents(
+			&dependentMock{name: "backend", calls: &calls},
+			&dependentMock{name: "frontend", calls: &calls},
+		)
+
+		err := restartRunner.Execute(context.Background(), runners.Change{})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(calls).To(Equal([]string{"stop frontend", "stop backend", "restart", "start backend", "start frontend"}))
+	})
+
+	It("leaves the dependents running unless asked to restart them", func() {
+		var calls []string
+
+		proc := restartMock{calls: &calls}
+
+		restartRunner := runners.Restart{}
+		restartRunner.Setup(&proc)
+		restartRunner.SetupDependents(&dependentMock{name: "backend", calls: &calls})
+
+		err := restartRunner.Execute(context.Background(), runners.Change{})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(calls).To(Equal([]string{"restart"}))
+	})
 })
 
 type restartMock struct {
 	called bool
+	calls  *[]string
 }
 
 func (r *restartMock) Restart(runCleanup bool) error {
 	r.called = true
 
+	if r.calls != nil {
+		*r.calls = append(*r.calls, "restart")
+	}
+
+	return nil
+}
+
+type dependentMock struct {
+	name  string
+	calls *[]string
+}
+
+func (d *dependentMock) Start() error {
+	*d.calls = append(*d.calls, "start "+d.name)
+
+	return nil
+}
+
+func (d *dependentMock) Stop() error {
+	*d.calls = append(*d.calls, "stop "+d.name)
+
 	return nil
 }
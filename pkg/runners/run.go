@@ -1,6 +1,7 @@
 package runners
 
 import (
+	"context"
 	"fmt"
 	"strings"
 )
@@ -8,24 +9,30 @@ import (
 type Run struct {
 	Run             []string `yaml:"run"`
 	ContinueOnError bool     `yaml:"continueOnError"`
-}
+	Timeout         Duration `yaml:"timeout"`
 
-var NameTemplate = "{{.Name}}"
+	Environment
+}
 
-func (r *Run) Execute(triggeringFileName string) error {
+// Execute runs each command in order, stopping as soon as ctx is cancelled.
+func (r *Run) Execute(ctx context.Context, change Change) error {
 	for _, command := range r.Run {
-		if strings.Contains(command, NameTemplate) {
-			command = strings.ReplaceAll(command, NameTemplate, triggeringFileName)
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
 
+		command = change.Expand(command)
+
 		proc := Process{
-			Type:     "task",
-			StartCmd: command,
+			Type:        "task",
+			StartCmd:    command,
+			Timeouts:    Timeouts{Start: r.Timeout},
+			Environment: r.Environment,
 		}
 
-		err := proc.Start()
+		err := proc.start(ctx, nil)
 		if err != nil {
-			if !r.ContinueOnError {
+			if !r.ContinueOnError || ctx.Err() != nil {
 				return err
 			}
 
@@ -35,3 +42,7 @@ func (r *Run) Execute(triggeringFileName string) error {
 
 	return nil
 }
+
+func (r *Run) String() string {
+	return fmt.Sprintf("run '%s'", strings.Join(r.Run, "', '"))
+}
@@ -1,8 +1,12 @@
 package runners_test
 
 import (
+	"context"
+	"errors"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/iplay88keys/watchtower/pkg/runners"
 
@@ -24,7 +28,7 @@ var _ = Describe("Run", func() {
 			},
 			ContinueOnError: false,
 		}
-		err = runner.Execute("")
+		err = runner.Execute(context.Background(), runners.Change{})
 		Expect(err).ToNot(HaveOccurred())
 
 		err = w.Close()
@@ -50,7 +54,7 @@ var _ = Describe("Run", func() {
 			},
 			ContinueOnError: false,
 		}
-		err = runner.Execute("test")
+		err = runner.Execute(context.Background(), runners.Change{Name: "test"})
 		Expect(err).ToNot(HaveOccurred())
 
 		err = w.Close()
@@ -64,6 +68,32 @@ var _ = Describe("Run", func() {
 		Eventually(string(out)).Should(Equal("Running: 'echo 'test''\ntest\n\n"))
 	})
 
+	It("passes the diff of the change to the command", func() {
+		stdout := os.Stdout
+		r, w, err := os.Pipe()
+		Expect(err).ToNot(HaveOccurred())
+		os.Stdout = w
+
+		runner := runners.Run{
+			Run: []string{
+				"printf %s {{.Diff}}",
+			},
+			ContinueOnError: false,
+		}
+		err = runner.Execute(context.Background(), runners.Change{Diff: "-'old'\n+new"})
+		Expect(err).ToNot(HaveOccurred())
+
+		err = w.Close()
+		Expect(err).ToNot(HaveOccurred())
+
+		out, err := ioutil.ReadAll(r)
+		Expect(err).ToNot(HaveOccurred())
+
+		os.Stdout = stdout
+
+		Eventually(string(out)).Should(HaveSuffix("-'old'\n+new\n"))
+	})
+
 	It("continues running processes even on failure if continue is true", func() {
 		stdout := os.Stdout
 		r, w, err := os.Pipe()
@@ -77,7 +107,7 @@ var _ = Describe("Run", func() {
 			},
 			ContinueOnError: true,
 		}
-		err = runner.Execute("")
+		err = runner.Execute(context.Background(), runners.Change{})
 		Expect(err).ToNot(HaveOccurred())
 
 		err = w.Close()
@@ -104,7 +134,7 @@ var _ = Describe("Run", func() {
 			},
 			ContinueOnError: false,
 		}
-		err = runner.Execute("")
+		err = runner.Execute(context.Background(), runners.Change{})
 		Expect(err).To(HaveOccurred())
 
 		err = w.Close()
@@ -117,4 +147,132 @@ var _ = Describe("Run", func() {
 
 		Eventually(string(out)).Should(Equal("Running: 'nonexistent-command'\nbash: nonexistent-command: command not found\n"))
 	})
+
+	It("kills every process the command started when the context is cancelled", func() {
+		stdout := os.Stdout
+		r, w, err := os.Pipe()
+		Expect(err).ToNot(HaveOccurred())
+		os.Stdout = w
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(200*time.Millisecond, cancel)
+
+		runner := runners.Run{
+			Run: []string{
+				"(sleep 5; echo 'late') & wait",
+				"echo 'next'",
+			},
+			ContinueOnError: true,
+		}
+
+		start := time.Now()
+		err = runner.Execute(ctx, runners.Change{})
+		Expect(err).To(MatchError(context.Canceled))
+		Expect(time.Since(start)).To(BeNumerically("<", 2*time.Second))
+
+		err = w.Close()
+		Expect(err).ToNot(HaveOccurred())
+
+		out, err := ioutil.ReadAll(r)
+		Expect(err).ToNot(HaveOccurred())
+
+		os.Stdout = stdout
+
+		Expect(string(out)).To(Equal("Running: '(sleep 5; echo 'late') & wait'\n"))
+	})
+
+	It("stops a command that runs past the timeout", func() {
+		stdout := os.Stdout
+		r, w, err := os.Pipe()
+		Expect(err).ToNot(HaveOccurred())
+		os.Stdout = w
+
+		runner := runners.Run{
+			Run: []string{
+				"sleep 5",
+				"echo 'next'",
+			},
+			Timeout: runners.Duration(200 * time.Millisecond),
+		}
+
+		start := time.Now()
+		err = runner.Execute(context.Background(), runners.Change{})
+		Expect(time.Since(start)).To(BeNumerically("<", 2*time.Second))
+
+		var timeoutErr *runners.TimeoutError
+		Expect(errors.As(err, &timeoutErr)).To(BeTrue())
+		Expect(timeoutErr.Command).To(Equal("sleep 5"))
+
+		err = w.Close()
+		Expect(err).ToNot(HaveOccurred())
+
+		out, err := ioutil.ReadAll(r)
+		Expect(err).ToNot(HaveOccurred())
+
+		os.Stdout = stdout
+
+		Expect(string(out)).To(Equal("Running: 'sleep 5'\nTimed out after 200ms, stopping: 'sleep 5'\n"))
+	})
+
+	It("runs the commands with the env files and variables added to the environment", func() {
+		dir, err := ioutil.TempDir("", "env")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		envFile := filepath.Join(dir, ".env")
+		err = ioutil.WriteFile(envFile, []byte("# comment\nexport BASE=/srv\n\nNAME=\"app ${BASE}\"\nRAW='${BASE}'\n"), 0644)
+		Expect(err).ToNot(HaveOccurred())
+
+		os.Setenv("WATCHTOWER_TEST_INHERITED", "inherited")
+		defer os.Unsetenv("WATCHTOWER_TEST_INHERITED")
+
+		stdout := os.Stdout
+		r, w, err := os.Pipe()
+		Expect(err).ToNot(HaveOccurred())
+		os.Stdout = w
+
+		runner := runners.Run{
+			Run: []string{
+				`echo "$NAME|$RAW|$BIN|$INHERITED"`,
+			},
+			Environment: runners.Environment{
+				EnvFile: []string{envFile},
+				Env: map[string]string{
+					"BIN":       "${BASE}/bin",
+					"INHERITED": "${WATCHTOWER_TEST_INHERITED}-x",
+				},
+			},
+		}
+
+		err = runner.Execute(context.Background(), runners.Change{})
+		Expect(err).ToNot(HaveOccurred())
+
+		err = w.Close()
+		Expect(err).ToNot(HaveOccurred())
+
+		out, err := ioutil.ReadAll(r)
+		Expect(err).ToNot(HaveOccurred())
+
+		os.Stdout = stdout
+
+		Expect(string(out)).To(ContainSubstring("\napp /srv|${BASE}|/srv/bin|inherited-x\n"))
+	})
+
+	It("returns an error if an env file can't be loaded", func() {
+		stdout := os.Stdout
+		os.Stdout = nil
+		defer func() {
+			os.Stdout = stdout
+		}()
+
+		runner := runners.Run{
+			Run: []string{"true"},
+			Environment: runners.Environment{
+				EnvFile: []string{"non-existent.env"},
+			},
+		}
+
+		err := runner.Execute(context.Background(), runners.Change{})
+		Expect(err).To(MatchError("couldn't load env file: open non-existent.env: no such file or directory"))
+	})
 })
@@ -0,0 +1,45 @@
+package runners
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"syscall"
+)
+
+// Signal is a syscall.Signal that is configured by name, such as "SIGTERM"
+// or "INT".
+type Signal syscall.Signal
+
+func (s *Signal) UnmarshalJSON(data []byte) error {
+	var value string
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return fmt.Errorf("signals must be strings such as 'SIGTERM': %s", string(data))
+	}
+
+	name := strings.TrimPrefix(strings.ToUpper(value), "SIG")
+
+	sig, ok := signals[name]
+	if !ok {
+		return fmt.Errorf("unknown signal: %s", value)
+	}
+
+	*s = Signal(sig)
+
+	return nil
+}
+
+func (s Signal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s Signal) String() string {
+	for name, sig := range signals {
+		if sig == syscall.Signal(s) {
+			return "SIG" + name
+		}
+	}
+
+	return syscall.Signal(s).String()
+}
@@ -0,0 +1,36 @@
+package runners_test
+
+import (
+	"encoding/json"
+	"syscall"
+
+	"github.com/iplay88keys/watchtower/pkg/runners"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Signal", func() {
+	It("unmarshals signal names with or without the SIG prefix", func() {
+		var s runners.Signal
+		err := json.Unmarshal([]byte(`"SIGINT"`), &s)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(syscall.Signal(s)).To(Equal(syscall.SIGINT))
+
+		err = json.Unmarshal([]byte(`"term"`), &s)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(syscall.Signal(s)).To(Equal(syscall.SIGTERM))
+	})
+
+	It("marshals back to a signal name", func() {
+		out, err := json.Marshal(runners.Signal(syscall.SIGTERM))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(out)).To(Equal(`"SIGTERM"`))
+	})
+
+	It("returns an error for unknown signals", func() {
+		var s runners.Signal
+		err := json.Unmarshal([]byte(`"SIGNOPE"`), &s)
+		Expect(err).To(MatchError("unknown signal: SIGNOPE"))
+	})
+})
@@ -0,0 +1,64 @@
+package runners
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// StopProcesses stops the processes, each one before the processes it
+// depends on, then runs the cleanup command of every process that was
+// started. Every process that was started has its stop command run, so that
+// a task that started something, such as 'docker compose up -d', can stop it
+// too, but only running background processes are signalled or killed. Once the context is done, whatever is still running is killed
+// straight away and the remaining cleanup commands are skipped. An error is
+// returned if any process couldn't be stopped or cleaned up.
+func StopProcesses(ctx context.Context, processes []*Process) error {
+	sorted, err := SortProcesses(processes)
+	if err != nil {
+		return err
+	}
+
+	var failures []string
+	for i := len(sorted) - 1; i >= 0; i-- {
+		process := sorted[i]
+
+		end := process.beginTeardown(ctx)
+		if process.Running() || process.hasLaunched() {
+			err := process.stop(ctx)
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("stopping '%s': %s", process.Name, err))
+			}
+		} else {
+			process.preventRestart()
+		}
+		end()
+
+		if process.CleanupCmd == "" || !process.hasLaunched() {
+			continue
+		}
+
+		if ctx.Err() != nil {
+			failures = append(failures, fmt.Sprintf("skipped cleaning up '%s': %s", process.Name, ctx.Err()))
+			continue
+		}
+
+		err := process.cleanup(ctx)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("cleaning up '%s': %s", process.Name, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("teardown failed: %s", strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+func (p *Process) hasLaunched() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.launched
+}
@@ -0,0 +1,133 @@
+package runners_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/iplay88keys/watchtower/pkg/runners"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StopProcesses", func() {
+	It("stops each process before its dependencies and cleans up after it", func() {
+		stdout := os.Stdout
+		r, w, err := os.Pipe()
+		Expect(err).ToNot(HaveOccurred())
+		os.Stdout = w
+
+		processes := []*runners.Process{{
+			Name:       "db",
+			Type:       "background",
+			StartCmd:   "sleep 30",
+			CleanupCmd: "echo 'db cleaned'",
+		}, {
+			Name:       "backend",
+			Type:       "background",
+			StartCmd:   "sleep 30",
+			CleanupCmd: "echo 'backend cleaned'",
+			DependsOn:  []runners.Dependency{{Name: "db"}},
+		}, {
+			Name:       "unused",
+			Type:       "task",
+			StartCmd:   "true",
+			CleanupCmd: "echo 'unused cleaned'",
+		}}
+
+		err = runners.StartProcesses(context.Background(), processes[:2])
+		Expect(err).ToNot(HaveOccurred())
+
+		err = runners.StopProcesses(context.Background(), processes)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = w.Close()
+		Expect(err).ToNot(HaveOccurred())
+
+		out, err := ioutil.ReadAll(r)
+		Expect(err).ToNot(HaveOccurred())
+
+		os.Stdout = stdout
+
+		Expect(string(out)).To(MatchRegexp("(?s)Stopping 'backend' with SIGTERM\n.*backend cleaned\n.*Stopping 'db' with SIGTERM\n.*db cleaned\n"))
+		Expect(string(out)).ToNot(ContainSubstring("unused cleaned"))
+		Expect(processes[0].Running()).To(BeFalse())
+		Expect(processes[1].Running()).To(BeFalse())
+	})
+
+	It("runs the stop command of a task that was started", func() {
+		stdout := os.Stdout
+		r, w, err := os.Pipe()
+		Expect(err).ToNot(HaveOccurred())
+		os.Stdout = w
+
+		processes := []*runners.Process{{
+			Name:       "compose",
+			Type:       "task",
+			StartCmd:   "echo 'compose up'",
+			StopCmd:    "echo 'compose down'",
+			CleanupCmd: "echo 'compose cleaned'",
+		}, {
+			Name:     "unused",
+			Type:     "task",
+			StartCmd: "true",
+			StopCmd:  "echo 'unused stopped'",
+		}}
+
+		err = runners.StartProcesses(context.Background(), processes[:1])
+		Expect(err).ToNot(HaveOccurred())
+
+		err = runners.StopProcesses(context.Background(), processes)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = w.Close()
+		Expect(err).ToNot(HaveOccurred())
+
+		out, err := ioutil.ReadAll(r)
+		Expect(err).ToNot(HaveOccurred())
+
+		os.Stdout = stdout
+
+		Expect(string(out)).To(MatchRegexp("(?s)compose up\n.*compose down\n.*compose cleaned\n"))
+		Expect(string(out)).ToNot(ContainSubstring("unused stopped"))
+	})
+
+	It("kills the processes straight away once the context is done", func() {
+		stdout := os.Stdout
+		r, w, err := os.Pipe()
+		Expect(err).ToNot(HaveOccurred())
+		os.Stdout = w
+
+		processes := []*runners.Process{{
+			Name:       "test",
+			Type:       "background",
+			StartCmd:   "trap '' TERM; sleep 30 & wait",
+			CleanupCmd: "echo 'cleaned'",
+		}}
+
+		err = runners.StartProcesses(context.Background(), processes)
+		Expect(err).ToNot(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		start := time.Now()
+		err = runners.StopProcesses(ctx, processes)
+		Expect(err).To(MatchError("teardown failed: skipped cleaning up 'test': context canceled"))
+		Expect(time.Since(start)).To(BeNumerically("<", 2*time.Second))
+
+		err = w.Close()
+		Expect(err).ToNot(HaveOccurred())
+
+		out, err := ioutil.ReadAll(r)
+		Expect(err).ToNot(HaveOccurred())
+
+		os.Stdout = stdout
+
+		Expect(string(out)).To(ContainSubstring("Killing 'test'\n"))
+		Expect(string(out)).ToNot(ContainSubstring("cleaned\n"))
+		Expect(processes[0].Running()).To(BeFalse())
+	})
+})
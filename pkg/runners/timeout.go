@@ -0,0 +1,30 @@
+package runners
+
+import (
+	"fmt"
+	"time"
+)
+
+// timeoutGracePeriod is how long a command that has timed out is given to
+// exit after being asked to stop, before it is killed.
+const timeoutGracePeriod = 5 * time.Second
+
+// Timeouts limit how long each of a process's commands may run for. Zero
+// means no limit. The start timeout only applies to tasks, as background
+// processes are expected to keep running.
+type Timeouts struct {
+	Start   Duration `json:"start"`
+	Stop    Duration `json:"stop"`
+	Restart Duration `json:"restart"`
+	Cleanup Duration `json:"cleanup"`
+}
+
+// TimeoutError is returned when a command runs for longer than its timeout.
+type TimeoutError struct {
+	Command string
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("'%s' timed out after %s", e.Command, e.Timeout)
+}
@@ -0,0 +1,202 @@
+package watchers
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	diffContext = 3
+
+	// maxDiffEdits bounds the work done comparing two files. Beyond it the
+	// diff replaces the whole file instead of finding the smallest change.
+	maxDiffEdits = 2000
+)
+
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// unifiedDiff returns a unified diff between two versions of a file, or ""
+// if they are the same.
+func unifiedDiff(oldName, newName string, oldContent, newContent []byte) string {
+	if string(oldContent) == string(newContent) {
+		return ""
+	}
+
+	ops := diffLines(splitLines(string(oldContent)), splitLines(string(newContent)))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+
+	// oldLine and newLine hold the line number each op starts at.
+	oldLine := make([]int, len(ops)+1)
+	newLine := make([]int, len(ops)+1)
+	for i, op := range ops {
+		oldLine[i+1] = oldLine[i]
+		newLine[i+1] = newLine[i]
+
+		if op.kind != '+' {
+			oldLine[i+1]++
+		}
+
+		if op.kind != '-' {
+			newLine[i+1]++
+		}
+	}
+
+	var hunkEnd int
+	for i := 0; i < len(ops); i++ {
+		if ops[i].kind == ' ' {
+			continue
+		}
+
+		start := i - diffContext
+		if start < hunkEnd {
+			start = hunkEnd
+		}
+
+		lastChange := i
+		for j := i; j < len(ops) && j-lastChange <= 2*diffContext; j++ {
+			if ops[j].kind != ' ' {
+				lastChange = j
+			}
+		}
+
+		end := lastChange + diffContext + 1
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n",
+			hunkRange(oldLine[start], oldLine[end]-oldLine[start]),
+			hunkRange(newLine[start], newLine[end]-newLine[start]))
+
+		for _, op := range ops[start:end] {
+			fmt.Fprintf(&out, "%c%s\n", op.kind, op.line)
+		}
+
+		hunkEnd = end
+		i = end - 1
+	}
+
+	return out.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// diffLines finds the shortest edit script turning a into b using Myers'
+// algorithm, returning every line of both tagged as kept (' '), removed
+// ('-') or added ('+').
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	maxEdits := n + m
+	if maxEdits > maxDiffEdits {
+		maxEdits = maxDiffEdits
+	}
+
+	// v holds the furthest x reached on each diagonal k, offset so that k
+	// can be negative. trace keeps v as it was before each round.
+	offset := maxEdits + 1
+	v := make([]int, 2*offset+1)
+
+	var trace [][]int
+	for d := 0; d <= maxEdits; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(a, b, trace)
+			}
+		}
+	}
+
+	return replaceAll(a, b)
+}
+
+func backtrack(a, b []string, trace [][]int) []diffOp {
+	var ops []diffOp
+
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		// trace[d] covers the diagonals -d-1 through d+1.
+		v := func(k int) int { return trace[d][k+d+1] }
+
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v(k-1) < v(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := v(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			ops = append(ops, diffOp{kind: ' ', line: a[x-1]})
+			x--
+			y--
+		}
+
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, diffOp{kind: '+', line: b[y-1]})
+			} else {
+				ops = append(ops, diffOp{kind: '-', line: a[x-1]})
+			}
+		}
+
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+
+	return ops
+}
+
+func replaceAll(a, b []string) []diffOp {
+	var ops []diffOp
+	for _, line := range a {
+		ops = append(ops, diffOp{kind: '-', line: line})
+	}
+
+	for _, line := range b {
+		ops = append(ops, diffOp{kind: '+', line: line})
+	}
+
+	return ops
+}
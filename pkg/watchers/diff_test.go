@@ -0,0 +1,94 @@
+package watchers
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func numberedLines(count int) string {
+	var lines []string
+	for i := 1; i <= count; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+var _ = Describe("unifiedDiff", func() {
+	It("returns nothing for identical content", func() {
+		Expect(unifiedDiff("a", "b", []byte("same\n"), []byte("same\n"))).To(BeEmpty())
+	})
+
+	It("diffs a changed line with surrounding context", func() {
+		before := numberedLines(10)
+		after := strings.Replace(before, "line 5\n", "line five\n", 1)
+
+		Expect(unifiedDiff("old/file", "new/file", []byte(before), []byte(after))).To(Equal(`--- old/file
++++ new/file
+@@ -2,7 +2,7 @@
+ line 2
+ line 3
+ line 4
+-line 5
++line five
+ line 6
+ line 7
+ line 8
+`))
+	})
+
+	It("splits changes far apart into separate hunks", func() {
+		before := numberedLines(20)
+		after := strings.Replace(before, "line 2\n", "", 1)
+		after = strings.Replace(after, "line 19\n", "line 19\nline 19.5\n", 1)
+
+		Expect(unifiedDiff("a", "b", []byte(before), []byte(after))).To(Equal(`--- a
++++ b
+@@ -1,5 +1,4 @@
+ line 1
+-line 2
+ line 3
+ line 4
+ line 5
+@@ -17,4 +16,5 @@
+ line 17
+ line 18
+ line 19
++line 19.5
+ line 20
+`))
+	})
+
+	It("diffs a file created from nothing", func() {
+		Expect(unifiedDiff("a", "b", nil, []byte("first\nsecond\n"))).To(Equal(`--- a
++++ b
+@@ -0,0 +1,2 @@
++first
++second
+`))
+	})
+
+	It("diffs a file emptied of everything", func() {
+		Expect(unifiedDiff("a", "b", []byte("first\n"), nil)).To(Equal(`--- a
++++ b
+@@ -1,1 +0,0 @@
+-first
+`))
+	})
+
+	It("replaces the whole file when the files are too different to compare", func() {
+		var before, after []string
+		for i := 0; i < maxDiffEdits; i++ {
+			before = append(before, fmt.Sprintf("old %d", i))
+			after = append(after, fmt.Sprintf("new %d", i))
+		}
+
+		ops := diffLines(before, after)
+		Expect(ops).To(HaveLen(2 * maxDiffEdits))
+		Expect(ops[0]).To(Equal(diffOp{kind: '-', line: "old 0"}))
+		Expect(ops[maxDiffEdits]).To(Equal(diffOp{kind: '+', line: "new 0"}))
+	})
+})
@@ -0,0 +1,252 @@
+package watchers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/iplay88keys/watchtower/pkg/runners"
+)
+
+// slots limit how many watches run their triggers at once and serialize the
+// watches that share a mutex group. Each is a channel holding a token for
+// every run that is using it.
+type slots struct {
+	mu     sync.Mutex
+	global chan struct{}
+	groups map[string]chan struct{}
+}
+
+func (s *slots) group(name string) chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.groups == nil {
+		s.groups = make(map[string]chan struct{})
+	}
+
+	group, ok := s.groups[name]
+	if !ok {
+		group = make(chan struct{}, 1)
+		s.groups[name] = group
+	}
+
+	return group
+}
+
+// SetMaxConcurrentTriggers limits how many watches can run their triggers at
+// the same time. It must be called before Watch, and zero means no limit.
+func (w *PathWatcher) SetMaxConcurrentTriggers(max int) {
+	w.slots.global = nil
+	if max > 0 {
+		w.slots.global = make(chan struct{}, max)
+	}
+}
+
+// acquire waits until a config may run its triggers, taking its mutex groups
+// in name order before a global slot so that two runs can't each hold what
+// the other is waiting for. It returns a function that releases them again.
+func (w *PathWatcher) acquire(ctx context.Context, config pathConfig) (func(), error) {
+	names := append([]string(nil), config.MutexGroups...)
+	sort.Strings(names)
+
+	var held []chan struct{}
+	release := func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			<-held[i]
+		}
+	}
+
+	for i, name := range names {
+		if i > 0 && name == names[i-1] {
+			continue
+		}
+
+		group := w.slots.group(name)
+		err := take(ctx, group, fmt.Sprintf("Waiting for mutex group '%s' to run '%s'", name, config.name))
+		if err != nil {
+			release()
+			return nil, err
+		}
+
+		held = append(held, group)
+	}
+
+	if w.slots.global != nil {
+		err := take(ctx, w.slots.global, fmt.Sprintf("Waiting for a free trigger slot to run '%s'", config.name))
+		if err != nil {
+			release()
+			return nil, err
+		}
+
+		held = append(held, w.slots.global)
+	}
+
+	return release, nil
+}
+
+func take(ctx context.Context, slot chan struct{}, waiting string) error {
+	select {
+	case slot <- struct{}{}:
+		return nil
+	default:
+	}
+
+	fmt.Println(waiting)
+
+	select {
+	case slot <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run calls fn once the config may run its triggers, keeping its slots until
+// fn returns.
+func (w *PathWatcher) run(ctx context.Context, config pathConfig, fn func() error) error {
+	release, err := w.acquire(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return fn()
+}
+
+// claim marks the triggers of a config as running for a change, returning
+// the context to run them with and the config as it was claimed. It returns
+// false if they are already running.
+func (w *PathWatcher) claim(configInd int, change runners.Change) (context.Context, pathConfig, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	config := &w.paths[configInd]
+	if config.running {
+		return nil, *config, false
+	}
+
+	ctx, cancel := context.WithCancel(w.ctx)
+	config.running = true
+	config.current = change
+	config.cancel = cancel
+
+	return ctx, *config, true
+}
+
+func (w *PathWatcher) isRunning(configInd int) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.paths[configInd].running
+}
+
+// start runs the triggers of a config for a change in the background so that
+// other watches aren't held up by it. Changes that arrive while they are
+// still running are skipped, unless the config restarts its triggers.
+func (w *PathWatcher) start(configInd int, change runners.Change) {
+	ctx, config, ok := w.claim(configInd, change)
+	if !ok {
+		if config.Concurrency == ConcurrencyRestart {
+			w.restart(configInd, change)
+			return
+		}
+
+		fmt.Printf("Skipping '%s': its triggers are still running\n", config.name)
+		if change.PrevPath != "" {
+			os.Remove(change.PrevPath)
+		}
+
+		return
+	}
+
+	w.triggers.Add(1)
+	go func() {
+		defer w.triggers.Done()
+
+		if change.PrevPath != "" {
+			defer os.Remove(change.PrevPath)
+		}
+
+		err := w.run(ctx, config, func() error {
+			return w.executeRunners(ctx, config, change)
+		})
+		// A failed run is only reported, so that the watch keeps watching
+		// for the change that fixes it.
+		if w.finish(configInd, ctx, err) {
+			fmt.Printf("Error running '%s': %s\n", config.name, err.Error())
+		}
+	}()
+}
+
+// restart cancels the running triggers of a config, which are then run again
+// for the changes they were running for merged with the new one.
+func (w *PathWatcher) restart(configInd int, change runners.Change) {
+	w.mu.Lock()
+	config := &w.paths[configInd]
+	if !config.running {
+		w.mu.Unlock()
+		w.start(configInd, change)
+
+		return
+	}
+
+	// The previous content of the cancelled change is removed when its run
+	// ends, so only the newer change's can be kept.
+	pending := config.current
+	pending.PrevPath = ""
+	if config.next != nil {
+		pending = *config.next
+	}
+
+	merged := change
+	if pending.Name != "" || len(pending.Names) > 0 {
+		merged = mergeChanges(pending, change)
+	}
+
+	config.next = &merged
+	cancel := config.cancel
+	w.mu.Unlock()
+
+	fmt.Printf("Cancelling the running triggers for '%s': %s, %s\n", config.name, change.Name, change.Op)
+	cancel()
+}
+
+// finish marks the triggers of a config as no longer running, then restarts
+// them or hands back any changes that were held for it in the meantime. It
+// reports whether err is a failure rather than the run being cancelled.
+func (w *PathWatcher) finish(configInd int, ctx context.Context, err error) bool {
+	failed := err != nil && ctx.Err() == nil
+
+	w.mu.Lock()
+	config := &w.paths[configInd]
+	config.running = false
+	config.cancel()
+	config.cancel = nil
+	next := config.next
+	config.next = nil
+	w.mu.Unlock()
+
+	if next != nil {
+		if w.ctx.Err() != nil {
+			if next.PrevPath != "" {
+				os.Remove(next.PrevPath)
+			}
+
+			return false
+		}
+
+		fmt.Printf("Restarting triggers for '%s'\n", config.name)
+		w.start(configInd, *next)
+
+		return false
+	}
+
+	if config.throttle != nil && config.throttle.holding() {
+		w.schedule(configInd, 0)
+	}
+
+	return failed
+}
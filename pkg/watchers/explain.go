@@ -0,0 +1,174 @@
+package watchers
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/iplay88keys/watchtower/pkg/runners"
+)
+
+// Explanation says whether a change to a path would run the triggers of a
+// watch and, if not, the rule that keeps it from doing so.
+type Explanation struct {
+	Name     string
+	Matched  bool
+	Reason   string
+	Triggers []string
+}
+
+// Explainer evaluates path watches against a path without watching anything,
+// to show which of them a change to it would trigger and why.
+type Explainer struct {
+	configs []pathConfig
+}
+
+func NewExplainer() *Explainer {
+	return &Explainer{}
+}
+
+func (e *Explainer) Add(path Path, runnerConfigs []*runners.Config, name string, opts Options) error {
+	pc, err := newPathConfig(path, runnerConfigs, name, opts)
+	if err != nil {
+		return err
+	}
+
+	e.configs = append(e.configs, pc)
+
+	return nil
+}
+
+// Explain returns an explanation for every watch of an op on path, in the
+// order their triggers would run.
+func (e *Explainer) Explain(path, op string) ([]Explanation, error) {
+	events, err := desiredEvents([]string{op})
+	if err != nil {
+		return nil, err
+	}
+
+	return e.explain(path, fsnotify.Op(events))
+}
+
+// Matches returns the names of the watches whose triggers a change to path
+// would run, in the order they would run them. Any event a watch wants
+// counts, and whether the content of path changed is not considered.
+func (e *Explainer) Matches(path string) ([]string, error) {
+	events, err := desiredEvents(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	explanations, err := e.explain(path, fsnotify.Op(events))
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, explanation := range explanations {
+		if explanation.Matched {
+			names = append(names, explanation.Name)
+		}
+	}
+
+	return names, nil
+}
+
+func (e *Explainer) explain(path string, op fsnotify.Op) ([]Explanation, error) {
+	absFileLoc, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not get absolute path for '%s'", path)
+	}
+
+	order := make([]int, len(e.configs))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return e.configs[order[i]].Priority > e.configs[order[j]].Priority
+	})
+
+	var explanations []Explanation
+	var claimedBy *pathConfig
+	for _, configInd := range order {
+		config := &e.configs[configInd]
+
+		reason, err := config.explain(absFileLoc, op)
+		if err != nil {
+			return nil, err
+		}
+
+		if reason == "" && claimedBy != nil {
+			reason = fmt.Sprintf("the change is claimed by '%s', which stops propagation", claimedBy.name)
+		}
+
+		explanation := Explanation{
+			Name:    config.name,
+			Matched: reason == "",
+			Reason:  reason,
+		}
+
+		for _, runner := range config.runners {
+			explanation.Triggers = append(explanation.Triggers, fmt.Sprint(runner.Config))
+		}
+
+		if explanation.Matched && config.StopPropagation {
+			claimedBy = config
+		}
+
+		explanations = append(explanations, explanation)
+	}
+
+	return explanations, nil
+}
+
+// explain returns the rule that keeps an op on absFileLoc from running the
+// config's triggers, or "" if none does. The rules are checked in the same
+// order as when an event is handled.
+func (c *pathConfig) explain(absFileLoc string, op fsnotify.Op) (string, error) {
+	fileLoc, root, ok := c.locate(absFileLoc, func() string { return resolvePath(absFileLoc) })
+	if !ok {
+		return fmt.Sprintf("it isn't within any of the watched paths: '%s'", strings.Join(c.Paths, "', '")), nil
+	}
+
+	if !root.contains(fileLoc) {
+		depth := depthBelow(root.abs, fileLoc)
+
+		switch {
+		case depth < 0:
+			return fmt.Sprintf("it isn't within any of the watched paths: '%s'", strings.Join(c.Paths, "', '")), nil
+		case !root.isDir:
+			return fmt.Sprintf("'%s' is a file, so only changes to it are watched", root.path), nil
+		case root.maxDepth >= 0:
+			return fmt.Sprintf("it is %d level(s) below '%s', which is only watched %d level(s) deep",
+				depth, root.path, root.maxDepth), nil
+		}
+
+		return fmt.Sprintf("it isn't watched beneath '%s'", root.path), nil
+	}
+
+	relative := root.relative(fileLoc)
+	exclusion, err := c.exclusion(relative)
+	if err != nil {
+		return "", err
+	}
+
+	if exclusion != "" {
+		return fmt.Sprintf("'%s' is excluded by '%s'", relative, exclusion), nil
+	}
+
+	for dir := filepath.Dir(fileLoc); depthBelow(root.abs, dir) >= 1; dir = filepath.Dir(dir) {
+		if c.hasStopMarker(dir) {
+			return fmt.Sprintf("it is inside '%s', which holds a stopAt marker", root.relative(dir)), nil
+		}
+	}
+
+	if c.desiredEvents&uint32(op) == 0 {
+		return fmt.Sprintf("it only watches for '%s' events", strings.Join(c.Events, "', '")), nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,160 @@
+package watchers_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/iplay88keys/watchtower/pkg/runners"
+	"github.com/iplay88keys/watchtower/pkg/watchers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Explainer", func() {
+	var (
+		tmpDir    string
+		explainer *watchers.Explainer
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "*")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(tmpDir, "frontend", "src"), os.ModePerm)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(tmpDir, "tools", "linter"), os.ModePerm)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(tmpDir, "tools", "linter", "go.mod"), nil, os.ModePerm)).To(Succeed())
+
+		explainer = watchers.NewExplainer()
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	run := func(commands ...string) []*runners.Config {
+		return []*runners.Config{{
+			Config: &runners.Run{Run: commands},
+		}, {
+			Config: &runners.Restart{Restart: "server"},
+		}}
+	}
+
+	It("lists the matching watches and their triggers in the order they would run", func() {
+		err := explainer.Add(watchers.Path{
+			Paths:     []string{tmpDir},
+			Recursive: true,
+		}, run("make"), "catch-all", watchers.Options{})
+		Expect(err).ToNot(HaveOccurred())
+
+		err = explainer.Add(watchers.Path{
+			Paths:     []string{filepath.Join(tmpDir, "frontend")},
+			Recursive: true,
+		}, run("npm run build", "npm test"), "frontend", watchers.Options{
+			Priority:        1,
+			StopPropagation: true,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		explanations, err := explainer.Explain(filepath.Join(tmpDir, "frontend", "src", "app.js"), "write")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(explanations).To(Equal([]watchers.Explanation{{
+			Name:     "frontend",
+			Matched:  true,
+			Triggers: []string{"run 'npm run build', 'npm test'", "restart 'server'"},
+		}, {
+			Name:     "catch-all",
+			Reason:   "the change is claimed by 'frontend', which stops propagation",
+			Triggers: []string{"run 'make'", "restart 'server'"},
+		}}))
+	})
+
+	It("lists the names of the watches a change of any kind would trigger", func() {
+		err := explainer.Add(watchers.Path{
+			Paths:     []string{tmpDir},
+			Recursive: true,
+			Events:    []string{"create"},
+		}, run("make"), "catch-all", watchers.Options{})
+		Expect(err).ToNot(HaveOccurred())
+
+		err = explainer.Add(watchers.Path{
+			Paths:     []string{filepath.Join(tmpDir, "frontend")},
+			Recursive: true,
+			StopAt:    []string{"package.json"},
+		}, run("npm run build"), "frontend", watchers.Options{
+			Priority:        1,
+			StopPropagation: true,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(explainer.Matches(filepath.Join(tmpDir, "frontend", "src", "app.js"))).To(Equal([]string{"frontend"}))
+		Expect(explainer.Matches(filepath.Join(tmpDir, "main.go"))).To(Equal([]string{"catch-all"}))
+		Expect(explainer.Matches(filepath.Join(os.TempDir(), "elsewhere"))).To(BeEmpty())
+
+		Expect(ioutil.WriteFile(filepath.Join(tmpDir, "frontend", "src", "package.json"), nil, os.ModePerm)).To(Succeed())
+		Expect(explainer.Matches(filepath.Join(tmpDir, "frontend", "src", "app.js"))).To(Equal([]string{"catch-all"}))
+	})
+
+	expectReason := func(path watchers.Path, file, op, reason string) {
+		err := explainer.Add(path, nil, "watch", watchers.Options{})
+		Expect(err).ToNot(HaveOccurred())
+
+		explanations, err := explainer.Explain(file, op)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(explanations).To(HaveLen(1))
+		Expect(explanations[0].Matched).To(BeFalse())
+		Expect(explanations[0].Reason).To(Equal(reason))
+	}
+
+	It("explains paths outside of the watched paths", func() {
+		frontend := filepath.Join(tmpDir, "frontend")
+		expectReason(watchers.Path{Paths: []string{frontend}, Recursive: true},
+			filepath.Join(tmpDir, "main.go"), "write",
+			"it isn't within any of the watched paths: '"+frontend+"'")
+	})
+
+	It("explains paths deeper than the watched depth", func() {
+		expectReason(watchers.Path{Paths: []string{tmpDir}},
+			filepath.Join(tmpDir, "frontend", "src", "app.js"), "write",
+			"it is 3 level(s) below '"+tmpDir+"', which is only watched 1 level(s) deep")
+	})
+
+	It("explains paths next to a watched file", func() {
+		file := filepath.Join(tmpDir, "tools", "linter", "go.mod")
+		expectReason(watchers.Path{Paths: []string{file}},
+			filepath.Join(tmpDir, "tools", "linter", "go.sum"), "write",
+			"it isn't within any of the watched paths: '"+file+"'")
+	})
+
+	It("explains paths beneath a watched file", func() {
+		file := filepath.Join(tmpDir, "tools", "linter", "go.mod")
+		expectReason(watchers.Path{Paths: []string{file}, Recursive: true},
+			filepath.Join(file, "vendor"), "write",
+			"'"+file+"' is a file, so only changes to it are watched")
+	})
+
+	It("explains excluded paths using the path the exclusion is matched against", func() {
+		expectReason(watchers.Path{Paths: []string{tmpDir}, Recursive: true, Exclusions: []string{`\.js$`}},
+			filepath.Join(tmpDir, "frontend", "src", "app.js"), "write",
+			"'"+filepath.Join(tmpDir, "frontend", "src", "app.js")+"' is excluded by '\\.js$'")
+	})
+
+	It("explains paths inside a directory holding a stopAt marker", func() {
+		expectReason(watchers.Path{Paths: []string{tmpDir}, Recursive: true, StopAt: []string{"go.mod"}},
+			filepath.Join(tmpDir, "tools", "linter", "main.go"), "write",
+			"it is inside '"+filepath.Join(tmpDir, "tools", "linter")+"', which holds a stopAt marker")
+	})
+
+	It("explains events the watch doesn't want", func() {
+		expectReason(watchers.Path{Paths: []string{tmpDir}, Recursive: true, Events: []string{"create", "remove"}},
+			filepath.Join(tmpDir, "main.go"), "write",
+			"it only watches for 'create', 'remove' events")
+	})
+
+	It("returns an error for an unknown op", func() {
+		_, err := explainer.Explain(tmpDir, "touch")
+		Expect(err).To(HaveOccurred())
+	})
+})
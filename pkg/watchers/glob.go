@@ -0,0 +1,46 @@
+package watchers
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// compileGlob turns a glob into a regular expression matching absolute
+// paths. '*' and '?' match within a single path component, while '**'
+// matches across any number of them. Relative globs are relative to the
+// working directory, like the roots of a Path watcher.
+func compileGlob(glob string) (*regexp.Regexp, error) {
+	if !filepath.IsAbs(glob) {
+		abs, err := filepath.Abs(glob)
+		if err != nil {
+			return nil, err
+		}
+
+		glob = abs
+	}
+
+	var pattern strings.Builder
+	pattern.WriteString("^")
+
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			pattern.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			pattern.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			pattern.WriteString("[^/]*")
+		case glob[i] == '?':
+			pattern.WriteString("[^/]")
+		default:
+			pattern.WriteString(regexp.QuoteMeta(string(glob[i])))
+		}
+	}
+
+	pattern.WriteString("$")
+
+	return regexp.Compile(pattern.String())
+}
@@ -0,0 +1,40 @@
+package watchers
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("compileGlob", func() {
+	table.DescribeTable("matching absolute globs",
+		func(glob, path string, expected bool) {
+			re, err := compileGlob(glob)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(re.MatchString(path)).To(Equal(expected))
+		},
+		table.Entry("an exact path", "/src/gen/api.go", "/src/gen/api.go", true),
+		table.Entry("a star within a component", "/src/gen/*.go", "/src/gen/api.go", true),
+		table.Entry("a star does not cross components", "/src/gen/*.go", "/src/gen/v1/api.go", false),
+		table.Entry("a question mark", "/src/cover?.out", "/src/cover1.out", true),
+		table.Entry("a double star across components", "/src/gen/**", "/src/gen/v1/api.go", true),
+		table.Entry("a double star directory prefix", "/src/**/*.pb.go", "/src/a/b/api.pb.go", true),
+		table.Entry("a double star directory prefix matching no directories", "/src/**/*.pb.go", "/src/api.pb.go", true),
+		table.Entry("a double star directory prefix with another extension", "/src/**/*.pb.go", "/src/a/api.go", false),
+		table.Entry("regular expression characters", "/src/gen/(v1)+.go", "/src/gen/(v1)+.go", true),
+		table.Entry("regular expression characters as literals", "/src/gen/(v1)+.go", "/src/gen/v1v1.go", false),
+	)
+
+	It("resolves relative globs against the working directory", func() {
+		wd, err := os.Getwd()
+		Expect(err).ToNot(HaveOccurred())
+
+		re, err := compileGlob("coverage/**")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(re.MatchString(filepath.Join(wd, "coverage", "index.html"))).To(BeTrue())
+		Expect(re.MatchString(filepath.Join(wd, "other", "index.html"))).To(BeFalse())
+	})
+})
@@ -0,0 +1,132 @@
+package watchers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// Normalizer removes differences that don't matter from files with one of
+// the given extensions before their content is compared.
+type Normalizer struct {
+	Extensions  []string `json:"extensions"`
+	Whitespace  bool     `json:"whitespace"`
+	LineComment string   `json:"lineComment"`
+}
+
+// contentHashes keeps a digest of each watched file so that events which
+// leave a file's content unchanged can be ignored.
+type contentHashes struct {
+	maxSize     int64
+	normalizers []Normalizer
+	digests     map[string][sha256.Size]byte
+}
+
+func newContentHashes(maxSize int64, normalizers []Normalizer) *contentHashes {
+	return &contentHashes{
+		maxSize:     maxSize,
+		normalizers: normalizers,
+		digests:     make(map[string][sha256.Size]byte),
+	}
+}
+
+// changed records the current digest of path and reports whether it differs
+// from the previous one. Paths that can't be hashed, such as directories or
+// files over the size limit, are always considered changed.
+func (h *contentHashes) changed(path string) bool {
+	digest, ok := h.digest(path)
+	if !ok {
+		delete(h.digests, path)
+		return true
+	}
+
+	previous, seen := h.digests[path]
+	h.digests[path] = digest
+
+	return !seen || previous != digest
+}
+
+func (h *contentHashes) seed(path string) {
+	if digest, ok := h.digest(path); ok {
+		h.digests[path] = digest
+	}
+}
+
+func (h *contentHashes) forget(path string) {
+	delete(h.digests, path)
+}
+
+func (h *contentHashes) digest(path string) ([sha256.Size]byte, bool) {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return [sha256.Size]byte{}, false
+	}
+
+	if h.maxSize > 0 && info.Size() > h.maxSize {
+		return [sha256.Size]byte{}, false
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return [sha256.Size]byte{}, false
+	}
+
+	for _, normalizer := range h.normalizers {
+		if normalizer.applies(path) {
+			content = normalizer.normalize(content)
+		}
+	}
+
+	return sha256.Sum256(content), true
+}
+
+func (n Normalizer) applies(path string) bool {
+	ext := filepath.Ext(path)
+	for _, extension := range n.Extensions {
+		if strings.EqualFold(ext, extension) || strings.EqualFold(ext, "."+extension) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalize drops lines that only hold a comment and, if whitespace is
+// ignored, blank lines and trailing whitespace, collapsing the other runs of
+// whitespace within a line to a single space.
+func (n Normalizer) normalize(content []byte) []byte {
+	if n.LineComment != "" {
+		var kept [][]byte
+		for _, line := range bytes.Split(content, []byte("\n")) {
+			if bytes.HasPrefix(bytes.TrimSpace(line), []byte(n.LineComment)) {
+				continue
+			}
+
+			kept = append(kept, line)
+		}
+
+		content = bytes.Join(kept, []byte("\n"))
+	}
+
+	if n.Whitespace {
+		var kept [][]byte
+		for _, line := range bytes.Split(content, []byte("\n")) {
+			fields := bytes.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+
+			// Indentation is kept as it is, since it is meaningful in
+			// languages such as YAML and Python.
+			indent := line[:len(line)-len(bytes.TrimLeftFunc(line, unicode.IsSpace))]
+			kept = append(kept, append(append([]byte(nil), indent...), bytes.Join(fields, []byte(" "))...))
+		}
+
+		content = bytes.Join(kept, []byte("\n"))
+	}
+
+	return content
+}
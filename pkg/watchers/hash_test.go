@@ -0,0 +1,115 @@
+package watchers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("contentHashes", func() {
+	var (
+		tmpDir string
+		file   string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "*")
+		Expect(err).ToNot(HaveOccurred())
+
+		file = filepath.Join(tmpDir, "main.go")
+		Expect(ioutil.WriteFile(file, []byte("package main\n"), os.ModePerm)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	It("only reports a change when the content differs from the last digest", func() {
+		hashes := newContentHashes(0, nil)
+		hashes.seed(file)
+
+		Expect(hashes.changed(file)).To(BeFalse())
+
+		Expect(ioutil.WriteFile(file, []byte("package other\n"), os.ModePerm)).To(Succeed())
+		Expect(hashes.changed(file)).To(BeTrue())
+		Expect(hashes.changed(file)).To(BeFalse())
+	})
+
+	It("treats files it has not seen as changed", func() {
+		hashes := newContentHashes(0, nil)
+		Expect(hashes.changed(file)).To(BeTrue())
+
+		hashes.forget(file)
+		Expect(hashes.changed(file)).To(BeTrue())
+	})
+
+	It("always treats files over the size limit as changed", func() {
+		hashes := newContentHashes(4, nil)
+		hashes.seed(file)
+
+		Expect(hashes.changed(file)).To(BeTrue())
+		Expect(hashes.changed(file)).To(BeTrue())
+	})
+
+	It("always treats directories as changed", func() {
+		hashes := newContentHashes(0, nil)
+		hashes.seed(tmpDir)
+
+		Expect(hashes.changed(tmpDir)).To(BeTrue())
+	})
+
+	It("ignores changes removed by a normalizer for matching extensions", func() {
+		hashes := newContentHashes(0, []Normalizer{{
+			Extensions: []string{"go"},
+			Whitespace: true,
+		}})
+		hashes.seed(file)
+
+		Expect(ioutil.WriteFile(file, []byte("package  main\n\n"), os.ModePerm)).To(Succeed())
+		Expect(hashes.changed(file)).To(BeFalse())
+	})
+})
+
+var _ = Describe("Normalizer", func() {
+	table.DescribeTable("normalize",
+		func(normalizer Normalizer, before, after string, same bool) {
+			Expect(string(normalizer.normalize([]byte(before))) == string(normalizer.normalize([]byte(after)))).To(Equal(same))
+		},
+		table.Entry("whitespace-only changes with whitespace ignored",
+			Normalizer{Whitespace: true}, "a := b", "a  :=  b\n", true),
+		table.Entry("removed whitespace with whitespace ignored",
+			Normalizer{Whitespace: true}, "a b", "ab", false),
+		table.Entry("changed indentation with whitespace ignored",
+			Normalizer{Whitespace: true}, "key:\n  value: 1\n", "key:\nvalue: 1\n", false),
+		table.Entry("blank lines and trailing whitespace with whitespace ignored",
+			Normalizer{Whitespace: true}, "a := b\n", "\na := b  \n\n", true),
+		table.Entry("other changes with whitespace ignored",
+			Normalizer{Whitespace: true}, "a := b", "a := c", false),
+		table.Entry("whitespace-only changes without whitespace ignored",
+			Normalizer{}, "a := b", "a  :=  b", false),
+		table.Entry("added comment lines",
+			Normalizer{LineComment: "//"}, "a := b\n", "// note\na := b\n", true),
+		table.Entry("indented comment lines",
+			Normalizer{LineComment: "#"}, "key: value\n", "  # note\nkey: value\n", true),
+		table.Entry("trailing comments",
+			Normalizer{LineComment: "//"}, "a := b\n", "a := b // note\n", false),
+		table.Entry("comment and whitespace changes together",
+			Normalizer{Whitespace: true, LineComment: "//"}, "a := b\n", "\n// note\na :=  b\n", true),
+	)
+
+	table.DescribeTable("applies",
+		func(extensions []string, path string, expected bool) {
+			Expect(Normalizer{Extensions: extensions}.applies(path)).To(Equal(expected))
+		},
+		table.Entry("an extension with a dot", []string{".go"}, "/src/main.go", true),
+		table.Entry("an extension without a dot", []string{"go"}, "/src/main.go", true),
+		table.Entry("an extension in a different case", []string{".GO"}, "/src/main.go", true),
+		table.Entry("a different extension", []string{".go"}, "/src/main.js", false),
+		table.Entry("a file without an extension", []string{".go"}, "/src/Makefile", false),
+	)
+})
@@ -0,0 +1,193 @@
+package watchers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// watchRoot is one of the configured root paths of a Path watcher.
+type watchRoot struct {
+	path     string
+	abs      string
+	resolved string
+	isDir    bool
+	maxDepth int
+}
+
+func newWatchRoot(path string, recursive bool, maxDepth int) (watchRoot, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return watchRoot{}, fmt.Errorf("could not get absolute path for '%s': %s", path, err.Error())
+	}
+
+	root := watchRoot{
+		path:     path,
+		abs:      abs,
+		resolved: abs,
+		isDir:    true,
+		maxDepth: -1,
+	}
+
+	switch {
+	case maxDepth > 0:
+		root.maxDepth = maxDepth
+	case !recursive:
+		root.maxDepth = 1
+	}
+
+	// Stat rather than Lstat so a root that links to a directory is treated
+	// as a directory.
+	if info, err := os.Stat(abs); err == nil {
+		root.isDir = info.IsDir()
+	}
+
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		root.resolved = resolved
+	}
+
+	return root, nil
+}
+
+// contains reports whether absFileLoc is watched by the root. A file root
+// only contains itself, while a directory root contains itself, its direct
+// children and, up to maxDepth, the rest of its descendants.
+func (r watchRoot) contains(absFileLoc string) bool {
+	depth := depthBelow(r.abs, absFileLoc)
+
+	switch {
+	case depth < 0:
+		return false
+	case depth == 0:
+		return true
+	case !r.isDir:
+		return false
+	}
+
+	return r.maxDepth == -1 || depth <= r.maxDepth
+}
+
+// logical maps a path with its symlinks resolved back to where it lives
+// beneath the root as configured.
+func (r watchRoot) logical(resolvedFileLoc string) (string, bool) {
+	if depthBelow(r.resolved, resolvedFileLoc) < 0 {
+		return "", false
+	}
+
+	rel, err := filepath.Rel(r.resolved, resolvedFileLoc)
+	if err != nil {
+		return "", false
+	}
+
+	return filepath.Join(r.abs, rel), true
+}
+
+// relative returns absFileLoc as it would be reached from the root as it was
+// configured, which is what exclusions are matched against.
+func (r watchRoot) relative(absFileLoc string) string {
+	rel, err := filepath.Rel(r.abs, absFileLoc)
+	if err != nil {
+		return absFileLoc
+	}
+
+	return filepath.Join(r.path, rel)
+}
+
+// depthBelow returns the number of path components absFileLoc is below root,
+// or -1 if it is not within root. Containment is decided per component, so
+// /src/api does not contain /src/api-gateway.
+func depthBelow(root, absFileLoc string) int {
+	rootComponents := splitPath(root)
+	fileComponents := splitPath(absFileLoc)
+
+	if len(fileComponents) < len(rootComponents) {
+		return -1
+	}
+
+	for i, component := range rootComponents {
+		if fileComponents[i] != component {
+			return -1
+		}
+	}
+
+	return len(fileComponents) - len(rootComponents)
+}
+
+// resolvePath resolves any symlinks in the directory of absFileLoc. The last
+// component is left as is since it may already have been removed, or be a
+// link that the event is about.
+func resolvePath(absFileLoc string) string {
+	dir, err := filepath.EvalSymlinks(filepath.Dir(absFileLoc))
+	if err != nil {
+		return absFileLoc
+	}
+
+	return filepath.Join(dir, filepath.Base(absFileLoc))
+}
+
+// locate finds the deepest root that absFileLoc lies beneath and returns the
+// path as it is known under that root. Events can be reported under a
+// different spelling of a directory when it is reachable through symlinks, so
+// the resolved path is tried when no root contains the path as given.
+func (c *pathConfig) locate(absFileLoc string, resolved func() string) (string, watchRoot, bool) {
+	var found bool
+	var best watchRoot
+	for _, root := range c.roots {
+		if depthBelow(root.abs, absFileLoc) < 0 {
+			continue
+		}
+
+		if !found || len(root.abs) > len(best.abs) {
+			best = root
+			found = true
+		}
+	}
+
+	if found {
+		return absFileLoc, best, true
+	}
+
+	resolvedFileLoc := resolved()
+	for _, root := range c.roots {
+		fileLoc, ok := root.logical(resolvedFileLoc)
+		if !ok {
+			continue
+		}
+
+		if !found || len(root.abs) > len(best.abs) {
+			absFileLoc = fileLoc
+			best = root
+			found = true
+		}
+	}
+
+	return absFileLoc, best, found
+}
+
+// isStopMarker reports whether absFileLoc is one of the stopAt markers in a
+// directory beneath the root. Markers in the root itself are ignored.
+func (c *pathConfig) isStopMarker(root watchRoot, absFileLoc string) bool {
+	if depthBelow(root.abs, filepath.Dir(absFileLoc)) < 1 {
+		return false
+	}
+
+	name := filepath.Base(absFileLoc)
+	for _, marker := range c.StopAt {
+		if marker == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasStopMarker reports whether dir contains any of the stopAt markers.
+func (c *pathConfig) hasStopMarker(dir string) bool {
+	for _, marker := range c.StopAt {
+		if _, err := os.Lstat(filepath.Join(dir, marker)); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
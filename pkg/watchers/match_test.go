@@ -0,0 +1,157 @@
+package watchers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+func dirRoot(path string, recursive bool) watchRoot {
+	root := watchRoot{path: path, abs: path, resolved: path, isDir: true, maxDepth: -1}
+	if !recursive {
+		root.maxDepth = 1
+	}
+
+	return root
+}
+
+func limitedRoot(path string, maxDepth int) watchRoot {
+	return watchRoot{path: path, abs: path, resolved: path, isDir: true, maxDepth: maxDepth}
+}
+
+func fileRoot(path string) watchRoot {
+	return watchRoot{path: path, abs: path, resolved: path, isDir: false, maxDepth: -1}
+}
+
+var _ = Describe("watchRoot", func() {
+	table.DescribeTable("contains",
+		func(root watchRoot, path string, expected bool) {
+			Expect(root.contains(path)).To(Equal(expected))
+		},
+		table.Entry("the root directory itself", dirRoot("/src/api", false), "/src/api", true),
+		table.Entry("a direct child", dirRoot("/src/api", false), "/src/api/main.go", true),
+		table.Entry("a nested descendant when recursive", dirRoot("/src/api", true), "/src/api/v1/handler.go", true),
+		table.Entry("a nested descendant when not recursive", dirRoot("/src/api", false), "/src/api/v1/handler.go", false),
+		table.Entry("a descendant within the max depth", limitedRoot("/src", 2), "/src/api/main.go", true),
+		table.Entry("a descendant beyond the max depth", limitedRoot("/src", 2), "/src/api/v1/main.go", false),
+		table.Entry("a sibling sharing a name prefix", dirRoot("/src/api", true), "/src/api-gateway/main.go", false),
+		table.Entry("a sibling file sharing a name prefix", dirRoot("/src/api", true), "/src/api.go", false),
+		table.Entry("a path containing the root further down", dirRoot("/src/api", true), "/other/src/api/main.go", false),
+		table.Entry("the parent of the root", dirRoot("/src/api", true), "/src", false),
+		table.Entry("an uncleaned path within the root", dirRoot("/src/api", true), "/src/web/../api/main.go", true),
+		table.Entry("an uncleaned path escaping the root", dirRoot("/src/api", true), "/src/api/../web/main.go", false),
+		table.Entry("anything below the filesystem root", dirRoot("/", true), "/etc/hosts", true),
+		table.Entry("the file root itself", fileRoot("/src/main.go"), "/src/main.go", true),
+		table.Entry("a file sharing the file root's name prefix", fileRoot("/src/main.go"), "/src/main.go.swp", false),
+		table.Entry("a sibling of the file root", fileRoot("/src/main.go"), "/src/other.go", false),
+		table.Entry("a path beneath the file root", fileRoot("/src/main.go"), "/src/main.go/x", false),
+	)
+
+	table.DescribeTable("depthBelow",
+		func(root, path string, expected int) {
+			Expect(depthBelow(root, path)).To(Equal(expected))
+		},
+		table.Entry("the root itself", "/src", "/src", 0),
+		table.Entry("a root with a trailing separator", "/src/", "/src/a", 1),
+		table.Entry("a nested path", "/src", "/src/a/b/c", 3),
+		table.Entry("a path sharing a name prefix", "/src", "/srcs/a", -1),
+		table.Entry("a shorter path", "/src/a", "/src", -1),
+	)
+
+	Context("with symlinks", func() {
+		var (
+			tmpDir  string
+			realDir string
+			linkDir string
+		)
+
+		BeforeEach(func() {
+			var err error
+			tmpDir, err = ioutil.TempDir("", "*")
+			Expect(err).ToNot(HaveOccurred())
+
+			tmpDir, err = filepath.EvalSymlinks(tmpDir)
+			Expect(err).ToNot(HaveOccurred())
+
+			realDir = filepath.Join(tmpDir, "real")
+			linkDir = filepath.Join(tmpDir, "link")
+
+			err = os.MkdirAll(filepath.Join(realDir, "nested"), os.ModePerm)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = ioutil.WriteFile(filepath.Join(realDir, "nested", "file"), nil, os.ModePerm)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = os.Symlink(realDir, linkDir)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(tmpDir)).To(Succeed())
+		})
+
+		It("treats a root linking to a directory as a directory", func() {
+			root, err := newWatchRoot(linkDir, true, 0)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(root.isDir).To(BeTrue())
+			Expect(root.resolved).To(Equal(realDir))
+			Expect(root.contains(filepath.Join(linkDir, "nested", "file"))).To(BeTrue())
+		})
+
+		It("maps events reported under the link target back to the configured root", func() {
+			root, err := newWatchRoot(linkDir, true, 0)
+			Expect(err).ToNot(HaveOccurred())
+
+			config := pathConfig{roots: []watchRoot{root}}
+			eventLoc := filepath.Join(realDir, "nested", "file")
+
+			fileLoc, found, ok := config.locate(eventLoc, func() string { return resolvePath(eventLoc) })
+			Expect(ok).To(BeTrue())
+			Expect(found.abs).To(Equal(linkDir))
+			Expect(fileLoc).To(Equal(filepath.Join(linkDir, "nested", "file")))
+		})
+
+		It("does not match a path outside the root once resolved", func() {
+			root, err := newWatchRoot(filepath.Join(realDir, "nested"), true, 0)
+			Expect(err).ToNot(HaveOccurred())
+
+			config := pathConfig{roots: []watchRoot{root}}
+			eventLoc := filepath.Join(linkDir, "other")
+
+			_, _, ok := config.locate(eventLoc, func() string { return resolvePath(eventLoc) })
+			Expect(ok).To(BeFalse())
+		})
+
+		It("walks through a root linking to a directory", func() {
+			var paths []string
+			err := walkPath(linkDir, walkOptions{followStart: true}, func(path string, info os.FileInfo, err error) error {
+				paths = append(paths, path)
+				return err
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(paths).To(Equal([]string{
+				linkDir,
+				filepath.Join(linkDir, "nested"),
+				filepath.Join(linkDir, "nested", "file"),
+			}))
+		})
+
+		It("does not follow links beneath the root", func() {
+			var paths []string
+			err := walkPath(tmpDir, walkOptions{followStart: true}, func(path string, info os.FileInfo, err error) error {
+				paths = append(paths, path)
+				return err
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(paths).To(ContainElement(linkDir))
+			Expect(paths).ToNot(ContainElement(filepath.Join(linkDir, "nested")))
+		})
+	})
+})
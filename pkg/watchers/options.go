@@ -0,0 +1,24 @@
+package watchers
+
+import "github.com/iplay88keys/watchtower/pkg/runners"
+
+const (
+	ConcurrencySkip    = "skip"
+	ConcurrencyRestart = "restart"
+)
+
+// Options control when the triggers of a watch run. Changes that arrive
+// while a watch is throttled are held and merged into its next run. Watches
+// that match the same change run from the highest priority down, stopping
+// after a watch that stops propagation. Watches run their triggers alongside
+// each other, except for watches that share one of their mutex groups. A
+// change while a watch is running its triggers is skipped, or with the
+// restart concurrency mode cancels them and runs them again.
+type Options struct {
+	MinInterval     runners.Duration `json:"minInterval"`
+	MaxRunsPerHour  int              `json:"maxRunsPerHour"`
+	Priority        int              `json:"priority"`
+	StopPropagation bool             `json:"stopPropagation"`
+	MutexGroups     []string         `json:"mutexGroups"`
+	Concurrency     string           `json:"concurrency"`
+}
@@ -1,14 +1,17 @@
 package watchers
 
 import (
+    "context"
     "errors"
     "fmt"
     "io/fs"
     "os"
     "path/filepath"
     "regexp"
+    "sort"
     "strings"
     "sync"
+    "time"
 
     "github.com/fsnotify/fsnotify"
 
@@ -17,31 +20,72 @@ import (
 
 const SHOULD_UPDATE_EVENT = uint32(fsnotify.Remove) | uint32(fsnotify.Rename)| uint32(fsnotify.Create)
 
+// renameWindow is how long a rename waits for the create of its new name.
+const renameWindow = 50 * time.Millisecond
+
 type Path struct {
-    Paths      []string `json:"paths"`
-    Recursive  bool     `json:"recursive"`
-    Exclusions []string `json:"exclusions"`
-    Events     []string `json:"events"`
+    Paths           []string     `json:"paths"`
+    Recursive       bool         `json:"recursive"`
+    Exclusions      []string     `json:"exclusions"`
+    Events          []string     `json:"events"`
+    FollowSymlinks  bool         `json:"followSymlinks"`
+    MaxDepth        int          `json:"maxDepth"`
+    StopAt          []string     `json:"stopAt"`
+    ContentHash     bool         `json:"contentHash"`
+    MaxHashSize     int64        `json:"maxHashSize"`
+    Normalize       []Normalizer `json:"normalize"`
+    Snapshot        bool         `json:"snapshot"`
+    MaxSnapshotSize int64        `json:"maxSnapshotSize"`
 }
 
 type PathWatcher struct {
     watcher *fsnotify.Watcher
 
-    paths []pathConfig
-    done  chan struct{}
-    quit  chan struct{}
+    paths    []pathConfig
+    order    []int
+    done     chan struct{}
+    quit     chan struct{}
+    released chan int
+
+    mu       sync.RWMutex
+    slots    slots
+    once     sync.Once
+    ctx      context.Context
+    cancel   context.CancelFunc
+    triggers sync.WaitGroup
+
+    suppressMu   sync.Mutex
+    suppressions []*suppression
+}
+
+type pathEvent struct {
+    fsnotify.Event
 
-    mu            sync.RWMutex
-    handlingEvent bool
+    oldName string
 }
 
 type pathConfig struct {
     Path
+    Options
 
     name          string
-    foundPaths    map[string]bool
+    roots         []watchRoot
+    found         *pathTree
+    stops         *pathTree
+    pending       map[string]string
+    hashes        *contentHashes
+    snapshots     *contentSnapshots
     desiredEvents uint32
     runners       []*runners.Config
+    outputs       map[*runners.Config][]*regexp.Regexp
+    throttle      *throttle
+    running       bool
+    current       runners.Change
+    next          *runners.Change
+    cancel        context.CancelFunc
+    state         string
+    paused        *runners.Change
+    pausedCount   int
 }
 
 func NewPathWatcher() (*PathWatcher, error) {
@@ -50,48 +94,112 @@ func NewPathWatcher() (*PathWatcher, error) {
         return nil, err
     }
 
+    ctx, cancel := context.WithCancel(context.Background())
+
     return &PathWatcher{
-        watcher: watcher,
-        done:    make(chan struct{}, 1),
-        quit:    make(chan struct{}, 1),
+        watcher:  watcher,
+        done:     make(chan struct{}, 1),
+        quit:     make(chan struct{}, 1),
+        released: make(chan int),
+        ctx:      ctx,
+        cancel:   cancel,
     }, nil
 }
 
-func (w *PathWatcher) Add(path Path, runnerConfigs []*runners.Config, name string) error {
+func (w *PathWatcher) Add(path Path, runnerConfigs []*runners.Config, name string, opts Options) error {
     fmt.Printf("Adding path watchers for '%s'\n", name)
 
-    events, err := desiredEvents(path.Events)
+    pc, err := newPathConfig(path, runnerConfigs, name, opts)
     if err != nil {
         return err
     }
 
+    for _, exclusion := range path.Exclusions {
+        fmt.Println("Excluding:", exclusion)
+    }
+
+    w.paths = append(w.paths, pc)
+    config := &w.paths[len(w.paths)-1]
+
+    for rootInd, root := range config.roots {
+        _, err = os.Stat(root.abs)
+        if err != nil {
+            err = w.waitFor(config, rootInd)
+        } else {
+            err = w.updatePathsAndWatchers(config, root, root.abs)
+        }
+
+        if err != nil {
+            w.paths = w.paths[:len(w.paths)-1]
+            return err
+        }
+    }
+
+    w.order = append(w.order, len(w.paths)-1)
+    sort.SliceStable(w.order, func(i, j int) bool {
+        return w.paths[w.order[i]].Priority > w.paths[w.order[j]].Priority
+    })
+
+    fmt.Println()
+
+    return nil
+}
+
+// newPathConfig checks a path watch's config and sets up what it needs,
+// without watching anything yet.
+func newPathConfig(path Path, runnerConfigs []*runners.Config, name string, opts Options) (pathConfig, error) {
+    events, err := desiredEvents(path.Events)
+    if err != nil {
+        return pathConfig{}, err
+    }
+
+    switch opts.Concurrency {
+    case "", ConcurrencySkip, ConcurrencyRestart:
+    default:
+        return pathConfig{}, fmt.Errorf("valid concurrency modes are: '%s' and '%s'", ConcurrencySkip, ConcurrencyRestart)
+    }
+
+    outputs, err := compileOutputs(runnerConfigs)
+    if err != nil {
+        return pathConfig{}, err
+    }
+
     pc := pathConfig{
         Path:          path,
+        Options:       opts,
+        found:         newPathTree(),
+        stops:         newPathTree(),
+        pending:       make(map[string]string),
         desiredEvents: events,
         runners:       runnerConfigs,
+        outputs:       outputs,
+        throttle:      newThrottle(opts),
         name:          name,
     }
 
-    for _, exclusion := range path.Exclusions {
-        fmt.Println("Excluding:", exclusion)
+    if path.ContentHash {
+        pc.hashes = newContentHashes(path.MaxHashSize, path.Normalize)
     }
 
-    foundPaths, err := w.updatePathsAndWatchers(path.Paths, path.Exclusions, path.Recursive, nil)
-    if err != nil {
-        return err
+    if path.Snapshot {
+        pc.snapshots = newContentSnapshots(path.MaxSnapshotSize)
     }
 
-    pc.foundPaths = foundPaths
+    for _, root := range path.Paths {
+        wr, err := newWatchRoot(root, path.Recursive, path.MaxDepth)
+        if err != nil {
+            return pathConfig{}, err
+        }
 
-    w.paths = append(w.paths, pc)
+        pc.roots = append(pc.roots, wr)
+    }
 
-    fmt.Println()
 
-    return nil
+    return pc, nil
 }
 
 func (w *PathWatcher) Watch() (func(), chan struct{}) {
-    events := make(chan fsnotify.Event)
+    events := make(chan pathEvent)
     errors := make(chan error)
 
     go w.watch(events, errors)
@@ -102,33 +210,57 @@ func (w *PathWatcher) Watch() (func(), chan struct{}) {
     }, w.quit
 }
 
-func (w *PathWatcher) watch(events chan fsnotify.Event, errors chan error) {
+func (w *PathWatcher) watch(events chan pathEvent, errors chan error) {
     defer close(w.quit)
     fmt.Println("Awaiting Events...")
 
+    // A rename is held briefly in case the create for its new name follows,
+    // so that both names can be reported together.
+    var renamed *fsnotify.Event
+    var renameTimeout <-chan time.Time
+
     for {
         select {
         case <-w.done:
             close(events)
             close(errors)
 
+            // Stopping cancels any triggers that are still running, which
+            // are waited on so that nothing they started outlives the watch.
+            w.triggers.Wait()
+
             return
+        case <-renameTimeout:
+            w.forward(events, pathEvent{Event: *renamed})
+
+            renamed = nil
+            renameTimeout = nil
         case event, ok := <-w.watcher.Events:
             if !ok {
                 return
             }
 
-            w.mu.RLock()
-            handling := w.handlingEvent
-            w.mu.RUnlock()
+            if renamed != nil {
+                oldName := renamed.Name
 
-            if handling {
-                continue
+                renamed = nil
+                renameTimeout = nil
+
+                if event.Op&fsnotify.Create != 0 {
+                    w.forward(events, pathEvent{Event: event, oldName: oldName})
+                    continue
+                }
+
+                w.forward(events, pathEvent{Event: fsnotify.Event{Name: oldName, Op: fsnotify.Rename}})
             }
 
-            w.updateHandlingEvent(true)
+            if event.Op == fsnotify.Rename {
+                renamed = &event
+                renameTimeout = time.After(renameWindow)
+                continue
+            }
 
-            events <- event
+            w.forward(events, pathEvent{Event: event})
         case err, ok := <-w.watcher.Errors:
             if !ok {
                 return
@@ -140,7 +272,15 @@ func (w *PathWatcher) watch(events chan fsnotify.Event, errors chan error) {
     }
 }
 
-func (w *PathWatcher) handleEvents(events chan fsnotify.Event, errors chan error) {
+// forward passes an event on to be handled.
+func (w *PathWatcher) forward(events chan pathEvent, event pathEvent) {
+    select {
+    case events <- event:
+    case <-w.done:
+    }
+}
+
+func (w *PathWatcher) handleEvents(events chan pathEvent, errors chan error) {
     for {
         select {
         case event, ok := <-events:
@@ -148,97 +288,44 @@ func (w *PathWatcher) handleEvents(events chan fsnotify.Event, errors chan error
                 return
             }
 
-            absFileLoc, err := filepath.Abs(event.Name)
-            if err != nil {
-                fmt.Printf("could not get absolute path for '%s'", event.Name)
-                return
-            }
+            // Every config still processes the event to keep its paths up
+            // to date once a config has claimed it.
+            var claimedBy *pathConfig
+            for _, configInd := range w.order {
+                config := &w.paths[configInd]
 
-            basePath, err := os.Getwd()
-            if err != nil {
-                fmt.Printf("could not get base path for '%s'", event.Name)
-                return
-            }
-
-            for configInd, config := range w.paths {
-                var cont bool
-                for _, exclusion := range config.Exclusions {
-                    relativePath := strings.TrimPrefix(event.Name, basePath+string(filepath.Separator))
-                    matched, err := regexp.MatchString(exclusion, relativePath)
-                    if err != nil {
-                        fmt.Printf("Error testing exclusion '%s': %s", exclusion, err.Error())
-                        return
-                    }
+                change, matched, err := w.processEvent(config, event)
+                if err != nil {
+                    fmt.Printf("Error updating paths for '%s': %s", config.name, err.Error())
 
-                    if matched {
-                        cont = true
-                        break
-                    }
+                    return
                 }
 
-                if cont {
+                if !matched {
                     continue
                 }
 
-                var found, foundExact, shouldUpdate bool
-                for foundPath := range config.foundPaths {
-                    if foundPath == absFileLoc {
-                        if config.desiredEvents&uint32(event.Op) != 0 {
-                            if SHOULD_UPDATE_EVENT&uint32(event.Op) != 0 {
-                                shouldUpdate = true
-                            }
-
-                            found = true
-                            foundExact = true
-
-                            err := w.executeRunners(config, absFileLoc, event.Op)
-                            if err != nil {
-                                fmt.Println("Error running: ", err.Error())
-
-                                w.updateHandlingEvent(false)
-                                return
-                            }
-                        }
+                if claimedBy != nil {
+                    fmt.Printf("Skipping '%s': event claimed by '%s'\n", config.name, claimedBy.name)
+                    if change.PrevPath != "" {
+                        os.Remove(change.PrevPath)
                     }
-                }
 
-                if !found {
-                    eventDepth := len(strings.Split(absFileLoc, string(filepath.Separator)))
-                    for foundPath := range config.foundPaths {
-                        foundDepth := len(strings.Split(foundPath, string(filepath.Separator)))
-                        if (!config.Recursive && strings.Contains(absFileLoc, foundPath) && foundDepth == eventDepth-1) || (strings.Contains(absFileLoc, foundPath) && config.Recursive) {
-                            if config.desiredEvents&uint32(event.Op) != 0 {
-                                if SHOULD_UPDATE_EVENT&uint32(event.Op) != 0 {
-                                    shouldUpdate = true
-                                }
-
-                                err := w.executeRunners(config, absFileLoc, event.Op)
-                                if err != nil {
-                                    fmt.Println("Error running: ", err.Error())
-
-                                    w.updateHandlingEvent(false)
-                                    return
-                                }
-
-                                break
-                            }
-                        }
-                    }
+                    continue
                 }
 
-                if !foundExact || shouldUpdate {
-                    foundPaths, err := w.updatePathsAndWatchers(config.Paths, config.Exclusions, config.Recursive, config.foundPaths)
-                    if err != nil {
-                        fmt.Printf("Error updating paths for '%s': %s", config.name, err.Error())
-
-                        return
-                    }
+                if config.StopPropagation {
+                    claimedBy = config
+                }
 
-                    w.paths[configInd].foundPaths = foundPaths
+                if w.holdPaused(configInd, change) {
+                    continue
                 }
-            }
 
-            w.updateHandlingEvent(false)
+                w.trigger(configInd, change)
+            }
+        case configInd := <-w.released:
+            w.releaseHeld(configInd)
         case err, ok := <-errors:
             if !ok {
                 return
@@ -250,12 +337,221 @@ func (w *PathWatcher) handleEvents(events chan fsnotify.Event, errors chan error
     }
 }
 
-func (w *PathWatcher) executeRunners(config pathConfig, filePath string, op fsnotify.Op) error {
+// processEvent decides whether an event matches a config, describes the
+// change if it does, and then updates the paths the config is watching.
+func (w *PathWatcher) processEvent(config *pathConfig, event pathEvent) (runners.Change, bool, error) {
+    op := event.Op
+    if event.oldName != "" {
+        op = fsnotify.Create
+    }
+
+    target, err := w.classify(config, event.Name, op)
+    if err != nil {
+        return runners.Change{}, false, err
+    }
+
+    var source eventTarget
+    if event.oldName != "" {
+        source, err = w.classify(config, event.oldName, fsnotify.Rename)
+        if err != nil {
+            return runners.Change{}, false, err
+        }
+    }
+
+    matched := target.matched || source.matched
+    if matched && w.selfTriggered(config, target.fileLoc) {
+        fmt.Println("Ignoring self-triggered change:", target.fileLoc)
+        matched = false
+    }
+
+    var change runners.Change
+    if matched {
+        change = runners.Change{
+            Name:    target.fileLoc,
+            OldName: source.fileLoc,
+            Op:      event.Op.String(),
+        }
+
+        if event.oldName != "" {
+            change.Op = fsnotify.Rename.String()
+        }
+
+        if config.snapshots != nil {
+            err = describeContent(config.snapshots, &change)
+            if err != nil {
+                fmt.Printf("Could not save the previous content of '%s': %s\n", change.Name, err.Error())
+            }
+        }
+    }
+
+    if event.oldName != "" {
+        err = w.update(config, source, fsnotify.Rename)
+        if err != nil {
+            return runners.Change{}, false, err
+        }
+    }
+
+    err = w.update(config, target, op)
+    if err != nil {
+        return runners.Change{}, false, err
+    }
+
+    return change, matched, nil
+}
+
+// eventTarget is where the path of an event lies within a config.
+type eventTarget struct {
+    fileLoc string
+    root    watchRoot
+    located bool
+    matched bool
+}
+
+func (w *PathWatcher) classify(config *pathConfig, name string, op fsnotify.Op) (eventTarget, error) {
+    absFileLoc, err := filepath.Abs(name)
+    if err != nil {
+        return eventTarget{}, fmt.Errorf("could not get absolute path for '%s'", name)
+    }
+
+    target := eventTarget{fileLoc: absFileLoc}
+
+    err = w.updatePending(config, absFileLoc, op)
+    if err != nil {
+        return eventTarget{}, err
+    }
+
+    fileLoc, root, ok := config.locate(absFileLoc, func() string { return resolvePath(absFileLoc) })
+    if !ok {
+        return target, nil
+    }
+
+    // Exclusions are matched against the path as it is reached from its
+    // root, the same as when the root is walked.
+    exclusion, err := config.exclusion(root.relative(fileLoc))
+    if err != nil {
+        return eventTarget{}, err
+    }
+
+    if exclusion != "" {
+        return target, nil
+    }
+
+    target.fileLoc = fileLoc
+    target.root = root
+    target.located = true
+    target.matched = config.matches(root, fileLoc, op)
+
+    return target, nil
+}
+
+// exclusion returns the first of the config's exclusions that matches a path
+// as it is reached from its root, or "" if none do.
+func (c *pathConfig) exclusion(fileLoc string) (string, error) {
+    for _, exclusion := range c.Exclusions {
+        matched, err := regexp.MatchString(exclusion, fileLoc)
+        if err != nil {
+            return "", fmt.Errorf("exclusion '%s' is an invalid regular expression: %s", exclusion, err.Error())
+        }
+
+        if matched {
+            return exclusion, nil
+        }
+    }
+
+    return "", nil
+}
+
+func (w *PathWatcher) update(config *pathConfig, target eventTarget, op fsnotify.Op) error {
+    if !target.located {
+        return nil
+    }
+
+    if config.found.contains(target.fileLoc) && SHOULD_UPDATE_EVENT&uint32(op) == 0 {
+        return nil
+    }
+
+    return w.updatePath(config, target.root, target.fileLoc, op)
+}
+
+// describeContent adds a diff of the changed file and the path to a copy of
+// its previous content to a change.
+func describeContent(snapshots *contentSnapshots, change *runners.Change) error {
+    prevLoc := change.Name
+    if change.OldName != "" {
+        prevLoc = change.OldName
+    }
+
+    prev, hadPrev, current, hasCurrent := snapshots.update(prevLoc, change.Name)
+    if !hadPrev && !hasCurrent {
+        return nil
+    }
+
+    change.Diff = unifiedDiff(prevLoc, change.Name, prev, current)
+
+    if !hadPrev {
+        return nil
+    }
+
+    prevPath, err := writePrevious(prevLoc, prev)
+    if err != nil {
+        return err
+    }
+
+    change.PrevPath = prevPath
+
+    return nil
+}
+
+func (w *PathWatcher) executeRunners(ctx context.Context, config pathConfig, change runners.Change) error {
     fmt.Printf("\n---------------------------------------\n")
-    fmt.Printf("Event matched for '%s': %s, %v\n\n", config.name, filePath, op)
+    if change.OldName != "" {
+        fmt.Printf("Event matched for '%s': %s -> %s, %s\n\n", config.name, change.OldName, change.Name, change.Op)
+    } else {
+        fmt.Printf("Event matched for '%s': %s, %s\n\n", config.name, change.Name, change.Op)
+    }
+
+    return w.runTriggers(ctx, config, change)
+}
 
+// RunTriggers runs the triggers of the path watches added with name once,
+// with an empty change, as if their paths had just changed. Changes to a
+// watch while its triggers run are handled as they are for any other run.
+func (w *PathWatcher) RunTriggers(name string) error {
+    for configInd := range w.paths {
+        if w.paths[configInd].name != name {
+            continue
+        }
+
+        ctx, config, ok := w.claim(configInd, runners.Change{})
+        if !ok {
+            fmt.Printf("Skipping '%s': its triggers are still running\n", config.name)
+            continue
+        }
+
+        if config.throttle != nil {
+            config.throttle.record(time.Now())
+        }
+
+        fmt.Printf("\n---------------------------------------\n")
+        fmt.Printf("Running triggers for '%s'\n\n", config.name)
+
+        err := w.run(ctx, config, func() error {
+            return w.runTriggers(ctx, config, runners.Change{})
+        })
+        if w.finish(configInd, ctx, err) {
+            return err
+        }
+    }
+
+    return nil
+}
+
+func (w *PathWatcher) runTriggers(ctx context.Context, config pathConfig, change runners.Change) error {
     for _, runner := range config.runners {
-        err := runner.Config.Execute(filePath)
+        s := w.suppress(config, runner)
+        err := runner.Config.Execute(ctx, change)
+        w.release(s, runner)
+
         if err != nil {
             return err
         }
@@ -264,86 +560,184 @@ func (w *PathWatcher) executeRunners(config pathConfig, filePath string, op fsno
     return nil
 }
 
-func (w *PathWatcher) updateHandlingEvent(status bool) {
-    w.mu.Lock()
-    w.handlingEvent = status
-    w.mu.Unlock()
+func (w *PathWatcher) stop() {
+    w.once.Do(func() {
+        w.cancel()
+        close(w.done)
+    })
 }
 
-func (w *PathWatcher) stop() {
-    close(w.done)
+// matches reports whether an event for fileLoc beneath root should run the
+// config's triggers.
+func (c *pathConfig) matches(root watchRoot, fileLoc string, op fsnotify.Op) bool {
+    if !root.contains(fileLoc) || c.stops.hasAncestor(fileLoc) || c.desiredEvents&uint32(op) == 0 {
+        return false
+    }
+
+    if c.hashes != nil && op&(fsnotify.Create|fsnotify.Write|fsnotify.Chmod) != 0 && !c.hashes.changed(fileLoc) {
+        fmt.Println("Ignoring unchanged content:", fileLoc)
+        return false
+    }
+
+    return true
 }
 
-func (w *PathWatcher) updatePathsAndWatchers(roots []string, exclusions []string, recursive bool, prevFoundPaths map[string]bool) (map[string]bool, error) {
-    foundPaths := make(map[string]bool)
+// updatePath incrementally updates the watched paths of a config after an
+// event, touching only the subtree beneath absFileLoc.
+func (w *PathWatcher) updatePath(config *pathConfig, root watchRoot, absFileLoc string, op fsnotify.Op) error {
+    if config.isStopMarker(root, absFileLoc) {
+        return w.updateStop(config, root, filepath.Dir(absFileLoc))
+    }
 
-    for _, root := range roots {
-        maxDepth := -1
+    if config.stops.hasAncestor(absFileLoc) {
+        return nil
+    }
 
-        absRoot, err := filepath.Abs(root)
-        if err != nil {
-            return nil, fmt.Errorf("could not get absolute path for '%s' during walk: %s", root, err.Error())
-        }
+    if op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+        w.removePaths(config, absFileLoc)
+        return nil
+    }
+
+    if _, err := os.Lstat(absFileLoc); err != nil {
+        w.removePaths(config, absFileLoc)
+        return nil
+    }
+
+    if absFileLoc != root.abs && !config.found.contains(filepath.Dir(absFileLoc)) {
+        return nil
+    }
 
-        if !recursive {
-            maxDepth = len(strings.Split(absRoot, string(filepath.Separator))) + 1
+    return w.updatePathsAndWatchers(config, root, absFileLoc)
+}
+
+// updateStop stops descending into dir once one of the stopAt markers appears
+// in it, and walks it again once the markers are gone.
+func (w *PathWatcher) updateStop(config *pathConfig, root watchRoot, dir string) error {
+    if !config.found.contains(dir) {
+        return nil
+    }
+
+    if config.hasStopMarker(dir) {
+        if !config.stops.insert(dir) {
+            return nil
         }
 
-        err = filepath.Walk(root, func(fileLoc string, info fs.FileInfo, err error) error {
-            if err != nil {
-                return errors.New(fmt.Sprintf("walk error for '%s': %s", fileLoc, err))
-            }
+        fmt.Println("Stopping at:", dir)
 
-            absFileLoc, err := filepath.Abs(fileLoc)
-            if err != nil {
-                return fmt.Errorf("could not get absolute path for '%s' during walk: %s", err, err.Error())
+        for _, removed := range config.found.remove(dir) {
+            if removed == dir {
+                continue
             }
 
-            depth := len(strings.Split(absFileLoc, string(filepath.Separator)))
+            fmt.Println("Removed:", removed)
+            w.unwatch(removed)
+        }
 
-            if maxDepth != -1 {
-                if depth > maxDepth {
-                    return nil
-                }
-            }
+        config.found.insert(dir)
 
-            for _, exclusion := range exclusions {
-                matched, err := regexp.MatchString(exclusion, fileLoc)
-                if err != nil {
-                    return fmt.Errorf("exclusion '%s' is an invalid regular expression: %s", exclusion, err.Error())
-                }
+        return nil
+    }
 
-                if matched {
-                    return nil
-                }
-            }
+    if !config.stops.contains(dir) {
+        return nil
+    }
 
-            err = w.watcher.Add(absFileLoc)
-            if err != nil {
-                fmt.Printf("Failed to add '%s': %s\n", absFileLoc, err.Error())
-                return nil
-            }
+    config.stops.remove(dir)
+
+    return w.updatePathsAndWatchers(config, root, dir)
+}
+
+func (w *PathWatcher) removePaths(config *pathConfig, absFileLoc string) {
+    config.stops.remove(absFileLoc)
+
+    for _, removed := range config.found.remove(absFileLoc) {
+        fmt.Println("Removed:", removed)
+        w.unwatch(removed)
+
+        if config.hashes != nil {
+            config.hashes.forget(removed)
+        }
+
+        if config.snapshots != nil {
+            config.snapshots.forget(removed)
+        }
+    }
+}
+
+func (w *PathWatcher) isWatched(absFileLoc string) bool {
+    for _, config := range w.paths {
+        if config.found.contains(absFileLoc) || config.isPending(absFileLoc) {
+            return true
+        }
+    }
+
+    return false
+}
+
+// updatePathsAndWatchers walks start, which must be within root, adding
+// every path that is not excluded and within the root's depth to the config
+// and the underlying watcher.
+func (w *PathWatcher) updatePathsAndWatchers(config *pathConfig, root watchRoot, start string) error {
+    opts := walkOptions{
+        followStart:    start == root.abs,
+        followSymlinks: config.FollowSymlinks,
+    }
+
+    if config.FollowSymlinks && start != root.abs {
+        opts.ancestors = resolvedAncestors(root.abs, start)
+    }
 
-            foundPaths[absFileLoc] = true
-            if _, inPrevFound := prevFoundPaths[absFileLoc]; !inPrevFound {
-                fmt.Println("Added:", absFileLoc)
+    return walkPath(start, opts, func(absFileLoc string, info fs.FileInfo, err error) error {
+        if err != nil {
+            return errors.New(fmt.Sprintf("walk error for '%s': %s", absFileLoc, err))
+        }
+
+        depth := depthBelow(root.abs, absFileLoc)
+        if root.maxDepth != -1 && depth > root.maxDepth {
+            if info.IsDir() {
+                return filepath.SkipDir
             }
 
             return nil
-        })
+        }
 
+        exclusion, err := config.exclusion(root.relative(absFileLoc))
         if err != nil {
-            return nil, err
+            return err
         }
-    }
 
-    for prevPath := range prevFoundPaths {
-        if _, ok := foundPaths[prevPath]; !ok {
-            fmt.Println("Removed:", prevPath)
+        if exclusion != "" {
+            return nil
+        }
+
+        err = w.watcher.Add(absFileLoc)
+        if err != nil {
+            fmt.Printf("Failed to add '%s': %s\n", absFileLoc, err.Error())
+            return nil
+        }
+
+        if config.found.insert(absFileLoc) {
+            fmt.Println("Added:", absFileLoc)
+        }
+
+        if config.hashes != nil && !info.IsDir() {
+            config.hashes.seed(absFileLoc)
+        }
+
+        if config.snapshots != nil && !info.IsDir() {
+            config.snapshots.seed(absFileLoc)
+        }
+
+        if info.IsDir() && absFileLoc != root.abs && config.hasStopMarker(absFileLoc) {
+            if config.stops.insert(absFileLoc) {
+                fmt.Println("Stopping at:", absFileLoc)
+            }
+
+            return filepath.SkipDir
         }
-    }
 
-    return foundPaths, nil
+        return nil
+    })
 }
 
 func desiredEvents(events []string) (uint32, error) {
@@ -6,6 +6,7 @@ import (
     "os"
     "path/filepath"
     "strings"
+    "sync"
     "time"
 
     "github.com/iplay88keys/watchtower/pkg/runners"
@@ -13,6 +14,7 @@ import (
 
     . "github.com/onsi/ginkgo"
     . "github.com/onsi/gomega"
+    "github.com/onsi/gomega/gbytes"
 )
 
 var _ = Describe("Path", func() {
@@ -21,6 +23,7 @@ var _ = Describe("Path", func() {
         r, w, err := os.Pipe()
         Expect(err).ToNot(HaveOccurred())
         os.Stdout = w
+        out := gbytes.BufferReader(r)
 
         tmpDir, err := ioutil.TempDir("", "*")
         Expect(err).ToNot(HaveOccurred())
@@ -53,7 +56,7 @@ var _ = Describe("Path", func() {
             },
         }}
 
-        err = pw.Add(p, runner, "")
+        err = pw.Add(p, runner, "", watchers.Options{})
         Expect(err).ToNot(HaveOccurred())
 
         stop, quit := pw.Watch()
@@ -67,6 +70,8 @@ var _ = Describe("Path", func() {
         err = f.Close()
         Expect(err).ToNot(HaveOccurred())
 
+        Eventually(out, 5).Should(gbytes.Say("Running: 'echo 'called''"))
+
         stop()
 
         Eventually(quit, 15).Should(BeClosed())
@@ -74,13 +79,11 @@ var _ = Describe("Path", func() {
         err = w.Close()
         Expect(err).ToNot(HaveOccurred())
 
-        out, err := ioutil.ReadAll(r)
-        Expect(err).ToNot(HaveOccurred())
+        Eventually(out.Closed).Should(BeTrue())
 
         os.Stdout = stdout
 
-        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Added: %s", f.Name())))
-        Expect(string(out)).To(ContainSubstring("Running: 'echo 'called''"))
+        Expect(string(out.Contents())).To(ContainSubstring(fmt.Sprintf("Added: %s", f.Name())))
     })
 
     It("recursively watches a path for file changes", func() {
@@ -120,7 +123,7 @@ var _ = Describe("Path", func() {
             },
         }}
 
-        err = pw.Add(p, runner, "")
+        err = pw.Add(p, runner, "", watchers.Options{})
         Expect(err).ToNot(HaveOccurred())
 
         stop, quit := pw.Watch()
@@ -183,7 +186,7 @@ var _ = Describe("Path", func() {
             },
         }}
 
-        err = pw.Add(p, runner, "")
+        err = pw.Add(p, runner, "", watchers.Options{})
         Expect(err).ToNot(HaveOccurred())
 
         stop, quit := pw.Watch()
@@ -258,7 +261,7 @@ var _ = Describe("Path", func() {
             },
         }}
 
-        err = pw.Add(p, runner, "watcher1")
+        err = pw.Add(p, runner, "watcher1", watchers.Options{})
         Expect(err).ToNot(HaveOccurred())
 
         stop, quit := pw.Watch()
@@ -302,7 +305,7 @@ var _ = Describe("Path", func() {
         Eventually(string(out)).ShouldNot(ContainSubstring(fmt.Sprintf("Running: 'echo '%s''", filepath.Join(tmpDir, "another"))))
     })
 
-    It("skips handling a event if another event is being handled", func() {
+    It("watches symlinked directories under the link when followSymlinks is set", func() {
         stdout := os.Stdout
         r, w, err := os.Pipe()
         Expect(err).ToNot(HaveOccurred())
@@ -311,6 +314,16 @@ var _ = Describe("Path", func() {
         tmpDir, err := ioutil.TempDir("", "*")
         Expect(err).ToNot(HaveOccurred())
 
+        sharedDir, err := ioutil.TempDir("", "*")
+        Expect(err).ToNot(HaveOccurred())
+
+        err = os.Mkdir(filepath.Join(sharedDir, "proto"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        link := filepath.Join(tmpDir, "shared")
+        err = os.Symlink(sharedDir, link)
+        Expect(err).ToNot(HaveOccurred())
+
         pw, err := watchers.NewPathWatcher()
         Expect(err).ToNot(HaveOccurred())
 
@@ -318,36 +331,27 @@ var _ = Describe("Path", func() {
             Paths: []string{
                 tmpDir,
             },
-            Recursive:  true,
-            Exclusions: nil,
+            Recursive:      true,
+            FollowSymlinks: true,
             Events: []string{
                 "create",
                 "write",
-                "remove",
-                "rename",
-                "chmod",
             },
         }
 
         runner := []*runners.Config{{
             Config: &runners.Run{
-                Run:             []string{"for i in 1 2 3 4 5; do echo \"Run #${i}\"; sleep 1; done"},
+                Run:             []string{"echo '{{.Name}}'"},
                 ContinueOnError: false,
             },
         }}
 
-        err = pw.Add(p, runner, "")
+        err = pw.Add(p, runner, "", watchers.Options{})
         Expect(err).ToNot(HaveOccurred())
 
         stop, quit := pw.Watch()
 
-        go func() {
-            GinkgoRecover()
-            err = os.Mkdir(filepath.Join(tmpDir, "new"), os.ModePerm)
-            Expect(err).ToNot(HaveOccurred())
-        }()
-
-        err = os.Mkdir(filepath.Join(tmpDir, "another"), os.ModePerm)
+        err = ioutil.WriteFile(filepath.Join(sharedDir, "proto", "api.proto"), []byte("syntax"), os.ModePerm)
         Expect(err).ToNot(HaveOccurred())
 
         time.Sleep(200 * time.Millisecond)
@@ -364,36 +368,171 @@ var _ = Describe("Path", func() {
 
         os.Stdout = stdout
 
-        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Added: %s", tmpDir)))
-        Expect(string(out)).To(ContainSubstring("Running"))
-        Expect(strings.Count(string(out), "Running")).To(Equal(1))
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Added: %s", filepath.Join(link, "proto"))))
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Running: 'echo '%s''", filepath.Join(link, "proto", "api.proto"))))
     })
 
-    It("returns an error if a path doesn't exist", func() {
-        osStdout := os.Stdout
-        osStderr := os.Stderr
+    It("only watches up to the max depth below a root", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
 
-        os.Stdout = nil
-        os.Stderr = nil
+        tmpDir, err := ioutil.TempDir("", "*")
+        Expect(err).ToNot(HaveOccurred())
+
+        err = os.MkdirAll(filepath.Join(tmpDir, "one", "two", "three"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        pw, err := watchers.NewPathWatcher()
+        Expect(err).ToNot(HaveOccurred())
+
+        p := watchers.Path{
+            Paths: []string{
+                tmpDir,
+            },
+            MaxDepth: 2,
+            Events: []string{
+                "create",
+            },
+        }
+
+        runner := []*runners.Config{{
+            Config: &runners.Run{
+                Run:             []string{"echo '{{.Name}}'"},
+                ContinueOnError: false,
+            },
+        }}
+
+        err = pw.Add(p, runner, "", watchers.Options{})
+        Expect(err).ToNot(HaveOccurred())
+
+        stop, quit := pw.Watch()
+
+        err = ioutil.WriteFile(filepath.Join(tmpDir, "one", "two", "three", "deep"), nil, os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        err = ioutil.WriteFile(filepath.Join(tmpDir, "one", "shallow"), nil, os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        stop()
+
+        Eventually(quit, 15).Should(BeClosed())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Added: %s", filepath.Join(tmpDir, "one", "two"))))
+        Expect(string(out)).ToNot(ContainSubstring(fmt.Sprintf("Added: %s", filepath.Join(tmpDir, "one", "two", "three"))))
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Running: 'echo '%s''", filepath.Join(tmpDir, "one", "shallow"))))
+        Expect(string(out)).ToNot(ContainSubstring("deep"))
+    })
+
+    It("stops recursing at directories containing a stopAt marker", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
 
         tmpDir, err := ioutil.TempDir("", "*")
         Expect(err).ToNot(HaveOccurred())
 
+        module := filepath.Join(tmpDir, "module")
+        err = os.MkdirAll(filepath.Join(module, "pkg"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        err = ioutil.WriteFile(filepath.Join(module, "go.mod"), nil, os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
         pw, err := watchers.NewPathWatcher()
         Expect(err).ToNot(HaveOccurred())
 
         p := watchers.Path{
             Paths: []string{
-                filepath.Join(tmpDir, "test"),
+                tmpDir,
+            },
+            Recursive: true,
+            StopAt: []string{
+                "go.mod",
             },
-            Recursive:  false,
-            Exclusions: nil,
             Events: []string{
                 "create",
+            },
+        }
+
+        runner := []*runners.Config{{
+            Config: &runners.Run{
+                Run:             []string{"echo '{{.Name}}'"},
+                ContinueOnError: false,
+            },
+        }}
+
+        err = pw.Add(p, runner, "", watchers.Options{})
+        Expect(err).ToNot(HaveOccurred())
+
+        stop, quit := pw.Watch()
+
+        err = ioutil.WriteFile(filepath.Join(module, "ignored"), nil, os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        err = ioutil.WriteFile(filepath.Join(tmpDir, "watched"), nil, os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        stop()
+
+        Eventually(quit, 15).Should(BeClosed())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Stopping at: %s", module)))
+        Expect(string(out)).ToNot(ContainSubstring(fmt.Sprintf("Added: %s", filepath.Join(module, "pkg"))))
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Running: 'echo '%s''", filepath.Join(tmpDir, "watched"))))
+        Expect(string(out)).ToNot(ContainSubstring("ignored"))
+    })
+
+    It("ignores writes that leave the content unchanged when contentHash is set", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        tmpDir, err := ioutil.TempDir("", "*")
+        Expect(err).ToNot(HaveOccurred())
+
+        file := filepath.Join(tmpDir, "test")
+        err = ioutil.WriteFile(file, []byte("original"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        pw, err := watchers.NewPathWatcher()
+        Expect(err).ToNot(HaveOccurred())
+
+        p := watchers.Path{
+            Paths: []string{
+                tmpDir,
+            },
+            Recursive:   true,
+            ContentHash: true,
+            Events: []string{
                 "write",
-                "remove",
-                "rename",
-                "chmod",
             },
         }
 
@@ -404,23 +543,54 @@ var _ = Describe("Path", func() {
             },
         }}
 
-        err = pw.Add(p, runner, "")
-        Expect(err).To(HaveOccurred())
+        err = pw.Add(p, runner, "", watchers.Options{})
+        Expect(err).ToNot(HaveOccurred())
 
-        os.Stdout = osStdout
-        os.Stderr = osStderr
-    })
+        stop, quit := pw.Watch()
 
-    It("returns an error if an unknown event is provided", func() {
-        osStdout := os.Stdout
-        osStderr := os.Stderr
+        err = ioutil.WriteFile(file, []byte("original"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
 
-        os.Stdout = nil
-        os.Stderr = nil
+        time.Sleep(200 * time.Millisecond)
+
+        err = ioutil.WriteFile(file, []byte("updated"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        stop()
+
+        Eventually(quit, 15).Should(BeClosed())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Ignoring unchanged content: %s", file)))
+        Expect(strings.Count(string(out), "Running: 'echo 'called''")).To(Equal(1))
+    })
+
+    It("ignores changes to a trigger's outputs until its quiet period has passed", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
 
         tmpDir, err := ioutil.TempDir("", "*")
         Expect(err).ToNot(HaveOccurred())
 
+        file := filepath.Join(tmpDir, "test")
+        err = ioutil.WriteFile(file, []byte("original"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        output := filepath.Join(tmpDir, "generated.out")
+        err = ioutil.WriteFile(output, []byte("original"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
         pw, err := watchers.NewPathWatcher()
         Expect(err).ToNot(HaveOccurred())
 
@@ -428,21 +598,1107 @@ var _ = Describe("Path", func() {
             Paths: []string{
                 tmpDir,
             },
-            Recursive:  true,
-            Exclusions: nil,
+            Recursive: true,
             Events: []string{
-                "unknown",
                 "write",
-                "remove",
-                "rename",
-                "chmod",
             },
         }
 
-        err = pw.Add(p, nil, "")
-        Expect(err).To(HaveOccurred())
+        runner := []*runners.Config{{
+            Config: &runners.Run{
+                Run:             []string{"echo 'called'"},
+                ContinueOnError: false,
+            },
+            Outputs:     []string{filepath.Join(tmpDir, "*.out")},
+            QuietPeriod: runners.Duration(5 * time.Second),
+        }}
 
-        os.Stdout = osStdout
-        os.Stderr = osStderr
+        err = pw.Add(p, runner, "", watchers.Options{})
+        Expect(err).ToNot(HaveOccurred())
+
+        stop, quit := pw.Watch()
+
+        err = ioutil.WriteFile(file, []byte("updated"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        err = ioutil.WriteFile(output, []byte("updated"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        stop()
+
+        Eventually(quit, 15).Should(BeClosed())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Event matched for '': %s, WRITE", file)))
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Ignoring self-triggered change: %s", output)))
+        Expect(string(out)).ToNot(ContainSubstring(fmt.Sprintf("Event matched for '': %s", output)))
     })
-})
+
+    It("ignores changes to a process's outputs while it is active", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        tmpDir, err := ioutil.TempDir("", "*")
+        Expect(err).ToNot(HaveOccurred())
+
+        output := filepath.Join(tmpDir, "generated.out")
+        err = ioutil.WriteFile(output, []byte("original"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        pw, err := watchers.NewPathWatcher()
+        Expect(err).ToNot(HaveOccurred())
+
+        p := watchers.Path{
+            Paths: []string{
+                tmpDir,
+            },
+            Events: []string{
+                "write",
+            },
+        }
+
+        runner := []*runners.Config{{
+            Config: &runners.Run{
+                Run:             []string{"echo 'called'"},
+                ContinueOnError: false,
+            },
+        }}
+
+        err = pw.Add(p, runner, "", watchers.Options{})
+        Expect(err).ToNot(HaveOccurred())
+
+        producer := &fakeProducer{running: true}
+        err = pw.IgnoreOutputs("generate", []string{filepath.Join(tmpDir, "*.out")}, producer)
+        Expect(err).ToNot(HaveOccurred())
+
+        stop, quit := pw.Watch()
+
+        err = ioutil.WriteFile(output, []byte("updated"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        producer.stop(time.Now().Add(-time.Second))
+
+        err = ioutil.WriteFile(output, []byte("updated again"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        stop()
+
+        Eventually(quit, 15).Should(BeClosed())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Ignoring self-triggered change: %s", output)))
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Event matched for '': %s, WRITE", output)))
+    })
+
+    It("passes the diff and previous content of a changed file when snapshot is set", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        tmpDir, err := ioutil.TempDir("", "*")
+        Expect(err).ToNot(HaveOccurred())
+
+        file := filepath.Join(tmpDir, "config.txt")
+        err = ioutil.WriteFile(file, []byte("before\n"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        pw, err := watchers.NewPathWatcher()
+        Expect(err).ToNot(HaveOccurred())
+
+        p := watchers.Path{
+            Paths: []string{
+                tmpDir,
+            },
+            Recursive: true,
+            Snapshot:  true,
+            Events: []string{
+                "write",
+            },
+        }
+
+        runner := []*runners.Config{{
+            Config: &runners.Run{
+                Run:             []string{"cat {{.PrevPath}}; echo {{.Diff}}"},
+                ContinueOnError: false,
+            },
+        }}
+
+        err = pw.Add(p, runner, "", watchers.Options{})
+        Expect(err).ToNot(HaveOccurred())
+
+        stop, quit := pw.Watch()
+
+        err = ioutil.WriteFile(file, []byte("after\n"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        stop()
+
+        Eventually(quit, 15).Should(BeClosed())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(ContainSubstring("\nbefore\n"))
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("--- %s\n+++ %s\n@@ -1,1 +1,1 @@\n-before\n+after\n", file, file)))
+    })
+
+    It("reports both names of a renamed file", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        tmpDir, err := ioutil.TempDir("", "*")
+        Expect(err).ToNot(HaveOccurred())
+
+        oldName := filepath.Join(tmpDir, "old")
+        newName := filepath.Join(tmpDir, "new")
+        err = ioutil.WriteFile(oldName, nil, os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        pw, err := watchers.NewPathWatcher()
+        Expect(err).ToNot(HaveOccurred())
+
+        p := watchers.Path{
+            Paths: []string{
+                tmpDir,
+            },
+            Recursive: true,
+            Events: []string{
+                "rename",
+            },
+        }
+
+        runner := []*runners.Config{{
+            Config: &runners.Run{
+                Run:             []string{"echo {{.OldName}} '->' '{{.Name}}'"},
+                ContinueOnError: false,
+            },
+        }}
+
+        err = pw.Add(p, runner, "", watchers.Options{})
+        Expect(err).ToNot(HaveOccurred())
+
+        stop, quit := pw.Watch()
+
+        err = os.Rename(oldName, newName)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        stop()
+
+        Eventually(quit, 15).Should(BeClosed())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Running: 'echo '%s' '->' '%s''", oldName, newName)))
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Removed: %s", oldName)))
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Added: %s", newName)))
+    })
+
+    It("holds changes that arrive within the min interval and merges them into the next run", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        tmpDir, err := ioutil.TempDir("", "*")
+        Expect(err).ToNot(HaveOccurred())
+
+        first := filepath.Join(tmpDir, "first")
+        second := filepath.Join(tmpDir, "second")
+        third := filepath.Join(tmpDir, "third")
+
+        pw, err := watchers.NewPathWatcher()
+        Expect(err).ToNot(HaveOccurred())
+
+        p := watchers.Path{
+            Paths: []string{
+                tmpDir,
+            },
+            Recursive: true,
+            Events: []string{
+                "create",
+            },
+        }
+
+        runner := []*runners.Config{{
+            Config: &runners.Run{
+                Run:             []string{"echo changed {{.Names}}"},
+                ContinueOnError: false,
+            },
+        }}
+
+        err = pw.Add(p, runner, "", watchers.Options{
+            MinInterval: runners.Duration(time.Second),
+        })
+        Expect(err).ToNot(HaveOccurred())
+
+        stop, quit := pw.Watch()
+
+        err = ioutil.WriteFile(first, []byte("first"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        err = ioutil.WriteFile(second, []byte("second"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        err = ioutil.WriteFile(third, []byte("third"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(1200 * time.Millisecond)
+
+        stop()
+
+        Eventually(quit, 15).Should(BeClosed())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("changed %s\n", first)))
+        Expect(string(out)).To(ContainSubstring("Deferring run for '' until"))
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("due to minInterval of 1s: %s, CREATE", second)))
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Holding change for '': %s, CREATE (2 changes held)", third)))
+        Expect(string(out)).To(ContainSubstring("Running 2 held change(s) for ''"))
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("changed %s %s\n", second, third)))
+    })
+
+    It("runs matching watches by priority until one stops propagation", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        tmpDir, err := ioutil.TempDir("", "*")
+        Expect(err).ToNot(HaveOccurred())
+
+        frontendDir := filepath.Join(tmpDir, "frontend")
+        err = os.Mkdir(frontendDir, os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        pw, err := watchers.NewPathWatcher()
+        Expect(err).ToNot(HaveOccurred())
+
+        catchAll := watchers.Path{
+            Paths: []string{
+                tmpDir,
+            },
+            Recursive: true,
+            Events: []string{
+                "create",
+            },
+        }
+
+        frontend := watchers.Path{
+            Paths: []string{
+                frontendDir,
+            },
+            Recursive: true,
+            Events: []string{
+                "create",
+            },
+        }
+
+        runnerFor := func(name string) []*runners.Config {
+            return []*runners.Config{{
+                Config: &runners.Run{
+                    Run:             []string{fmt.Sprintf("echo 'ran %s'", name)},
+                    ContinueOnError: false,
+                },
+            }}
+        }
+
+        err = pw.Add(catchAll, runnerFor("catch-all"), "catch-all", watchers.Options{})
+        Expect(err).ToNot(HaveOccurred())
+
+        err = pw.Add(frontend, runnerFor("frontend"), "frontend", watchers.Options{
+            Priority:        10,
+            StopPropagation: true,
+        })
+        Expect(err).ToNot(HaveOccurred())
+
+        stop, quit := pw.Watch()
+
+        err = ioutil.WriteFile(filepath.Join(frontendDir, "app.js"), []byte("app"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        stop()
+
+        Eventually(quit, 15).Should(BeClosed())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(ContainSubstring("\nran frontend\n"))
+        Expect(string(out)).To(ContainSubstring("Skipping 'catch-all': event claimed by 'frontend'"))
+        Expect(string(out)).ToNot(ContainSubstring("\nran catch-all\n"))
+    })
+
+    Context("when several watches are triggered at once", func() {
+        var (
+            stdout *os.File
+            r, w   *os.File
+            tmpDir string
+            pw     *watchers.PathWatcher
+        )
+
+        BeforeEach(func() {
+            var err error
+
+            stdout = os.Stdout
+            r, w, err = os.Pipe()
+            Expect(err).ToNot(HaveOccurred())
+            os.Stdout = w
+
+            tmpDir, err = ioutil.TempDir("", "*")
+            Expect(err).ToNot(HaveOccurred())
+
+            pw, err = watchers.NewPathWatcher()
+            Expect(err).ToNot(HaveOccurred())
+        })
+
+        addWatch := func(name, command string, opts watchers.Options) string {
+            dir := filepath.Join(tmpDir, name)
+            err := os.Mkdir(dir, os.ModePerm)
+            Expect(err).ToNot(HaveOccurred())
+
+            p := watchers.Path{
+                Paths: []string{
+                    dir,
+                },
+                Recursive: true,
+                Events: []string{
+                    "create",
+                },
+            }
+
+            runner := []*runners.Config{{
+                Config: &runners.Run{
+                    Run:             []string{command},
+                    ContinueOnError: false,
+                },
+            }}
+
+            err = pw.Add(p, runner, name, opts)
+            Expect(err).ToNot(HaveOccurred())
+
+            return dir
+        }
+
+        runBoth := func(backendDir, frontendDir string) string {
+            stop, quit := pw.Watch()
+
+            err := ioutil.WriteFile(filepath.Join(backendDir, "main.go"), []byte("main"), os.ModePerm)
+            Expect(err).ToNot(HaveOccurred())
+
+            time.Sleep(200 * time.Millisecond)
+
+            err = ioutil.WriteFile(filepath.Join(frontendDir, "app.js"), []byte("app"), os.ModePerm)
+            Expect(err).ToNot(HaveOccurred())
+
+            time.Sleep(1500 * time.Millisecond)
+
+            stop()
+
+            Eventually(quit, 15).Should(BeClosed())
+
+            err = w.Close()
+            Expect(err).ToNot(HaveOccurred())
+
+            out, err := ioutil.ReadAll(r)
+            Expect(err).ToNot(HaveOccurred())
+
+            os.Stdout = stdout
+
+            return string(out)
+        }
+
+        It("runs the triggers of independent watches alongside each other", func() {
+            backendDir := addWatch("backend", "sleep 1; echo 'backend done'", watchers.Options{})
+            frontendDir := addWatch("frontend", "echo 'frontend done'", watchers.Options{})
+
+            out := runBoth(backendDir, frontendDir)
+
+            Expect(out).To(ContainSubstring("\nbackend done\n"))
+            Expect(out).To(ContainSubstring("\nfrontend done\n"))
+            Expect(strings.Index(out, "frontend done\n")).To(BeNumerically("<", strings.Index(out, "backend done\n")))
+        })
+
+        It("serializes watches that share a mutex group", func() {
+            opts := watchers.Options{MutexGroups: []string{"build"}}
+            backendDir := addWatch("backend", "sleep 1; echo 'backend done'", opts)
+            frontendDir := addWatch("frontend", "echo 'frontend done'", opts)
+
+            out := runBoth(backendDir, frontendDir)
+
+            Expect(out).To(ContainSubstring("Waiting for mutex group 'build' to run 'frontend'"))
+            Expect(out).To(ContainSubstring("\nfrontend done\n"))
+            Expect(strings.Index(out, "backend done\n")).To(BeNumerically("<", strings.Index(out, "frontend done\n")))
+        })
+
+        It("runs no more watches at once than the max concurrent triggers", func() {
+            pw.SetMaxConcurrentTriggers(1)
+
+            backendDir := addWatch("backend", "sleep 1; echo 'backend done'", watchers.Options{})
+            frontendDir := addWatch("frontend", "echo 'frontend done'", watchers.Options{})
+
+            out := runBoth(backendDir, frontendDir)
+
+            Expect(out).To(ContainSubstring("Waiting for a free trigger slot to run 'frontend'"))
+            Expect(out).To(ContainSubstring("\nfrontend done\n"))
+            Expect(strings.Index(out, "backend done\n")).To(BeNumerically("<", strings.Index(out, "frontend done\n")))
+        })
+    })
+
+    It("cancels running triggers and runs them again for every change when concurrency is restart", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        tmpDir, err := ioutil.TempDir("", "*")
+        Expect(err).ToNot(HaveOccurred())
+
+        first := filepath.Join(tmpDir, "first")
+        second := filepath.Join(tmpDir, "second")
+
+        pw, err := watchers.NewPathWatcher()
+        Expect(err).ToNot(HaveOccurred())
+
+        p := watchers.Path{
+            Paths: []string{
+                tmpDir,
+            },
+            Recursive: true,
+            Events: []string{
+                "create",
+            },
+        }
+
+        runner := []*runners.Config{{
+            Config: &runners.Run{
+                Run:             []string{"echo started {{.Names}}; sleep 1; echo 'finished'"},
+                ContinueOnError: false,
+            },
+        }}
+
+        err = pw.Add(p, runner, "", watchers.Options{
+            Concurrency: watchers.ConcurrencyRestart,
+        })
+        Expect(err).ToNot(HaveOccurred())
+
+        stop, quit := pw.Watch()
+
+        err = ioutil.WriteFile(first, []byte("first"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(300 * time.Millisecond)
+
+        err = ioutil.WriteFile(second, []byte("second"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(1500 * time.Millisecond)
+
+        stop()
+
+        Eventually(quit, 15).Should(BeClosed())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("\nstarted %s\n", first)))
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Cancelling the running triggers for '': %s, CREATE", second)))
+        Expect(string(out)).To(ContainSubstring("Restarting triggers for ''"))
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("\nstarted %s %s\n", first, second)))
+        Expect(strings.Count(string(out), "\nfinished\n")).To(Equal(1))
+    })
+
+    It("returns an error if an unknown concurrency mode is provided", func() {
+        pw, err := watchers.NewPathWatcher()
+        Expect(err).ToNot(HaveOccurred())
+
+        err = pw.Add(watchers.Path{}, nil, "", watchers.Options{Concurrency: "queue"})
+        Expect(err).To(MatchError("valid concurrency modes are: 'skip' and 'restart'"))
+    })
+
+    It("holds changes while paused and runs them as one batch when resumed", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        tmpDir, err := ioutil.TempDir("", "*")
+        Expect(err).ToNot(HaveOccurred())
+
+        first := filepath.Join(tmpDir, "first")
+        second := filepath.Join(tmpDir, "second")
+        third := filepath.Join(tmpDir, "third")
+
+        pw, err := watchers.NewPathWatcher()
+        Expect(err).ToNot(HaveOccurred())
+
+        p := watchers.Path{
+            Paths: []string{
+                tmpDir,
+            },
+            Recursive: true,
+            Events: []string{
+                "create",
+            },
+        }
+
+        runner := []*runners.Config{{
+            Config: &runners.Run{
+                Run:             []string{"echo changed {{.Names}}"},
+                ContinueOnError: false,
+            },
+        }}
+
+        err = pw.Add(p, runner, "backend", watchers.Options{})
+        Expect(err).ToNot(HaveOccurred())
+
+        Expect(pw.Pause("missing")).To(MatchError("no watch named 'missing'"))
+
+        stop, quit := pw.Watch()
+
+        err = pw.Pause("backend")
+        Expect(err).ToNot(HaveOccurred())
+
+        err = ioutil.WriteFile(first, []byte("first"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        err = ioutil.WriteFile(second, []byte("second"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        Expect(pw.Status()).To(Equal([]watchers.WatchStatus{{
+            Name:  "backend",
+            State: watchers.StatePaused,
+            Held:  2,
+        }}))
+
+        err = pw.Resume("backend")
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        err = pw.Mute("backend")
+        Expect(err).ToNot(HaveOccurred())
+
+        err = ioutil.WriteFile(third, []byte("third"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        err = pw.Resume("backend")
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        stop()
+
+        Eventually(quit, 15).Should(BeClosed())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Holding change for paused 'backend': %s, CREATE (2 changes held)", second)))
+        Expect(string(out)).To(ContainSubstring("Resumed 'backend', running 2 held change(s)"))
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("\nchanged %s %s\n", first, second)))
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Ignoring change for muted 'backend': %s, CREATE", third)))
+        Expect(string(out)).To(ContainSubstring("Resumed 'backend'\n"))
+        Expect(strings.Count(string(out), "\nchanged ")).To(Equal(1))
+    })
+
+    It("keeps watching when a trigger fails", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+        out := gbytes.BufferReader(r)
+
+        tmpDir, err := ioutil.TempDir("", "*")
+        Expect(err).ToNot(HaveOccurred())
+
+        pw, err := watchers.NewPathWatcher()
+        Expect(err).ToNot(HaveOccurred())
+
+        p := watchers.Path{
+            Paths: []string{
+                tmpDir,
+            },
+            Recursive: true,
+            Events: []string{
+                "create",
+            },
+        }
+
+        runner := []*runners.Config{{
+            Config: &runners.Run{
+                Run:             []string{"exit 1", "echo 'skipped'"},
+                ContinueOnError: false,
+            },
+        }}
+
+        err = pw.Add(p, runner, "build", watchers.Options{})
+        Expect(err).ToNot(HaveOccurred())
+
+        stop, quit := pw.Watch()
+
+        err = ioutil.WriteFile(filepath.Join(tmpDir, "first"), []byte("test"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        Eventually(out, 5).Should(gbytes.Say("Error running 'build': exit status 1"))
+        Consistently(quit, "200ms").ShouldNot(BeClosed())
+
+        err = ioutil.WriteFile(filepath.Join(tmpDir, "second"), []byte("test"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        Eventually(out, 5).Should(gbytes.Say("Error running 'build': exit status 1"))
+
+        stop()
+
+        Eventually(quit, 15).Should(BeClosed())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        Eventually(out.Closed).Should(BeTrue())
+
+        os.Stdout = stdout
+
+        Expect(string(out.Contents())).ToNot(ContainSubstring("Running: 'echo 'skipped''"))
+    })
+
+    It("runs the triggers of a watch by name with an empty change", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        tmpDir, err := ioutil.TempDir("", "*")
+        Expect(err).ToNot(HaveOccurred())
+
+        pw, err := watchers.NewPathWatcher()
+        Expect(err).ToNot(HaveOccurred())
+
+        p := watchers.Path{
+            Paths: []string{
+                tmpDir,
+            },
+            Events: []string{
+                "write",
+            },
+        }
+
+        build := []*runners.Config{{
+            Config: &runners.Run{
+                Run:             []string{"echo 'build:{{.Name}}'"},
+                ContinueOnError: false,
+            },
+        }}
+
+        test := []*runners.Config{{
+            Config: &runners.Run{
+                Run:             []string{"echo 'test'"},
+                ContinueOnError: false,
+            },
+        }}
+
+        err = pw.Add(p, build, "build", watchers.Options{})
+        Expect(err).ToNot(HaveOccurred())
+
+        err = pw.Add(p, test, "test", watchers.Options{})
+        Expect(err).ToNot(HaveOccurred())
+
+        stop, quit := pw.Watch()
+
+        err = pw.RunTriggers("build")
+        Expect(err).ToNot(HaveOccurred())
+
+        stop()
+
+        Eventually(quit, 15).Should(BeClosed())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(ContainSubstring("Running triggers for 'build'"))
+        Expect(string(out)).To(ContainSubstring("\nbuild:\n"))
+        Expect(string(out)).ToNot(ContainSubstring("Running: 'echo 'test''"))
+    })
+
+    It("skips handling a event if another event is being handled", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        tmpDir, err := ioutil.TempDir("", "*")
+        Expect(err).ToNot(HaveOccurred())
+
+        pw, err := watchers.NewPathWatcher()
+        Expect(err).ToNot(HaveOccurred())
+
+        p := watchers.Path{
+            Paths: []string{
+                tmpDir,
+            },
+            Recursive:  true,
+            Exclusions: nil,
+            Events: []string{
+                "create",
+                "write",
+                "remove",
+                "rename",
+                "chmod",
+            },
+        }
+
+        runner := []*runners.Config{{
+            Config: &runners.Run{
+                Run:             []string{"for i in 1 2 3 4 5; do echo \"Run #${i}\"; sleep 1; done"},
+                ContinueOnError: false,
+            },
+        }}
+
+        err = pw.Add(p, runner, "", watchers.Options{})
+        Expect(err).ToNot(HaveOccurred())
+
+        stop, quit := pw.Watch()
+
+        go func() {
+            GinkgoRecover()
+            err = os.Mkdir(filepath.Join(tmpDir, "new"), os.ModePerm)
+            Expect(err).ToNot(HaveOccurred())
+        }()
+
+        err = os.Mkdir(filepath.Join(tmpDir, "another"), os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        stop()
+
+        Eventually(quit, 15).Should(BeClosed())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Added: %s", tmpDir)))
+        Expect(string(out)).To(ContainSubstring("Running"))
+        Expect(strings.Count(string(out), "Running")).To(Equal(1))
+    })
+
+    It("waits for a root that doesn't exist yet", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        tmpDir, err := ioutil.TempDir("", "*")
+        Expect(err).ToNot(HaveOccurred())
+
+        root := filepath.Join(tmpDir, "build", "out")
+
+        pw, err := watchers.NewPathWatcher()
+        Expect(err).ToNot(HaveOccurred())
+
+        p := watchers.Path{
+            Paths: []string{
+                root,
+            },
+            Recursive:  false,
+            Exclusions: nil,
+            Events: []string{
+                "create",
+            },
+        }
+
+        runner := []*runners.Config{{
+            Config: &runners.Run{
+                Run:             []string{"echo '{{.Name}}'"},
+                ContinueOnError: false,
+            },
+        }}
+
+        err = pw.Add(p, runner, "", watchers.Options{})
+        Expect(err).ToNot(HaveOccurred())
+
+        stop, quit := pw.Watch()
+
+        err = os.MkdirAll(root, os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        err = ioutil.WriteFile(filepath.Join(root, "app"), nil, os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        stop()
+
+        Eventually(quit, 15).Should(BeClosed())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Warning: '%s' does not exist, waiting for it to be created", root)))
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Found: %s", root)))
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Running: 'echo '%s''", filepath.Join(root, "app"))))
+    })
+
+    It("waits for a root that is a dangling symlink", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        tmpDir, err := ioutil.TempDir("", "*")
+        Expect(err).ToNot(HaveOccurred())
+
+        root := filepath.Join(tmpDir, "out")
+        err = os.Symlink(filepath.Join(tmpDir, "missing"), root)
+        Expect(err).ToNot(HaveOccurred())
+
+        pw, err := watchers.NewPathWatcher()
+        Expect(err).ToNot(HaveOccurred())
+
+        p := watchers.Path{
+            Paths: []string{
+                root,
+            },
+        }
+
+        runner := []*runners.Config{{
+            Config: &runners.Run{
+                Run: []string{"echo '{{.Name}}'"},
+            },
+        }}
+
+        err = pw.Add(p, runner, "", watchers.Options{})
+        Expect(err).ToNot(HaveOccurred())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Warning: '%s' does not exist, waiting for it to be created", root)))
+        Expect(string(out)).ToNot(ContainSubstring("Failed to add"))
+    })
+
+    It("goes back to waiting when a root is removed", func() {
+        stdout := os.Stdout
+        r, w, err := os.Pipe()
+        Expect(err).ToNot(HaveOccurred())
+        os.Stdout = w
+
+        tmpDir, err := ioutil.TempDir("", "*")
+        Expect(err).ToNot(HaveOccurred())
+
+        root := filepath.Join(tmpDir, "build")
+        err = os.Mkdir(root, os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        pw, err := watchers.NewPathWatcher()
+        Expect(err).ToNot(HaveOccurred())
+
+        p := watchers.Path{
+            Paths: []string{
+                root,
+            },
+            Recursive:  false,
+            Exclusions: nil,
+            Events: []string{
+                "create",
+            },
+        }
+
+        runner := []*runners.Config{{
+            Config: &runners.Run{
+                Run:             []string{"echo '{{.Name}}'"},
+                ContinueOnError: false,
+            },
+        }}
+
+        err = pw.Add(p, runner, "", watchers.Options{})
+        Expect(err).ToNot(HaveOccurred())
+
+        stop, quit := pw.Watch()
+
+        err = os.Remove(root)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        err = os.Mkdir(root, os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        err = ioutil.WriteFile(filepath.Join(root, "app"), nil, os.ModePerm)
+        Expect(err).ToNot(HaveOccurred())
+
+        time.Sleep(200 * time.Millisecond)
+
+        stop()
+
+        Eventually(quit, 15).Should(BeClosed())
+
+        err = w.Close()
+        Expect(err).ToNot(HaveOccurred())
+
+        out, err := ioutil.ReadAll(r)
+        Expect(err).ToNot(HaveOccurred())
+
+        os.Stdout = stdout
+
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Removed: %s", root)))
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Warning: '%s' does not exist, waiting for it to be created", root)))
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Found: %s", root)))
+        Expect(string(out)).To(ContainSubstring(fmt.Sprintf("Running: 'echo '%s''", filepath.Join(root, "app"))))
+    })
+
+    It("returns an error if an unknown event is provided", func() {
+        osStdout := os.Stdout
+        osStderr := os.Stderr
+
+        os.Stdout = nil
+        os.Stderr = nil
+
+        tmpDir, err := ioutil.TempDir("", "*")
+        Expect(err).ToNot(HaveOccurred())
+
+        pw, err := watchers.NewPathWatcher()
+        Expect(err).ToNot(HaveOccurred())
+
+        p := watchers.Path{
+            Paths: []string{
+                tmpDir,
+            },
+            Recursive:  true,
+            Exclusions: nil,
+            Events: []string{
+                "unknown",
+                "write",
+                "remove",
+                "rename",
+                "chmod",
+            },
+        }
+
+        err = pw.Add(p, nil, "", watchers.Options{})
+        Expect(err).To(HaveOccurred())
+
+        os.Stdout = osStdout
+        os.Stderr = osStderr
+    })
+})
+
+type fakeProducer struct {
+    mu      sync.Mutex
+    running bool
+    stopped time.Time
+}
+
+func (p *fakeProducer) Active() (bool, time.Time) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    return p.running, p.stopped
+}
+
+func (p *fakeProducer) stop(at time.Time) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    p.running = false
+    p.stopped = at
+}
@@ -0,0 +1,165 @@
+package watchers
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/iplay88keys/watchtower/pkg/runners"
+)
+
+const (
+	StateActive = "active"
+	StatePaused = "paused"
+	StateMuted  = "muted"
+)
+
+// WatchStatus describes a watch as it is at the moment.
+type WatchStatus struct {
+	Name    string
+	State   string
+	Running bool
+	Held    int
+}
+
+// Pause stops the watches added with name from running their triggers,
+// holding the changes made to them meanwhile to run as one batch when they
+// are resumed.
+func (w *PathWatcher) Pause(name string) error {
+	return w.setState(name, StatePaused)
+}
+
+// Mute stops the watches added with name from running their triggers,
+// ignoring the changes made to them meanwhile.
+func (w *PathWatcher) Mute(name string) error {
+	return w.setState(name, StateMuted)
+}
+
+// Resume lets the watches added with name run their triggers again, running
+// them for the changes that were held while they were paused.
+func (w *PathWatcher) Resume(name string) error {
+	configInds, err := w.named(name)
+	if err != nil {
+		return err
+	}
+
+	for _, configInd := range configInds {
+		w.mu.Lock()
+		config := &w.paths[configInd]
+		config.state = StateActive
+		held, count := config.paused, config.pausedCount
+		config.paused = nil
+		config.pausedCount = 0
+		w.mu.Unlock()
+
+		if held == nil {
+			fmt.Printf("Resumed '%s'\n", name)
+			continue
+		}
+
+		fmt.Printf("Resumed '%s', running %d held change(s)\n", name, count)
+		w.trigger(configInd, *held)
+	}
+
+	return nil
+}
+
+// Status returns the status of every watch, in the order they were added.
+func (w *PathWatcher) Status() []WatchStatus {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var statuses []WatchStatus
+	for _, config := range w.paths {
+		state := config.state
+		if state == "" {
+			state = StateActive
+		}
+
+		statuses = append(statuses, WatchStatus{
+			Name:    config.name,
+			State:   state,
+			Running: config.running,
+			Held:    config.pausedCount,
+		})
+	}
+
+	return statuses
+}
+
+func (w *PathWatcher) setState(name, state string) error {
+	configInds, err := w.named(name)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	for _, configInd := range configInds {
+		config := &w.paths[configInd]
+		config.state = state
+
+		if state == StateMuted && config.paused != nil {
+			if config.paused.PrevPath != "" {
+				os.Remove(config.paused.PrevPath)
+			}
+
+			config.paused = nil
+			config.pausedCount = 0
+		}
+	}
+	w.mu.Unlock()
+
+	if state == StatePaused {
+		fmt.Printf("Paused '%s'\n", name)
+	} else {
+		fmt.Printf("Muted '%s'\n", name)
+	}
+
+	return nil
+}
+
+func (w *PathWatcher) named(name string) ([]int, error) {
+	var configInds []int
+	for configInd := range w.paths {
+		if w.paths[configInd].name == name {
+			configInds = append(configInds, configInd)
+		}
+	}
+
+	if len(configInds) == 0 {
+		return nil, fmt.Errorf("no watch named '%s'", name)
+	}
+
+	return configInds, nil
+}
+
+// holdPaused keeps a change from running the triggers of a paused or muted config,
+// returning false if the config is active.
+func (w *PathWatcher) holdPaused(configInd int, change runners.Change) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	config := &w.paths[configInd]
+	switch config.state {
+	case StatePaused:
+		if config.paused == nil {
+			config.paused = &change
+		} else {
+			merged := mergeChanges(*config.paused, change)
+			config.paused = &merged
+		}
+
+		config.pausedCount++
+		fmt.Printf("Holding change for paused '%s': %s, %s (%d changes held)\n", config.name, change.Name, change.Op, config.pausedCount)
+
+		return true
+	case StateMuted:
+		fmt.Printf("Ignoring change for muted '%s': %s, %s\n", config.name, change.Name, change.Op)
+		if change.PrevPath != "" {
+			os.Remove(change.PrevPath)
+		}
+
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,135 @@
+package watchers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// waitFor watches the nearest existing ancestor of a root that does not exist
+// so that the root can be attached once it is created. If the root appears
+// while the ancestor is being set up it is attached straight away.
+func (w *PathWatcher) waitFor(config *pathConfig, rootInd int) error {
+	root := config.roots[rootInd]
+
+	for {
+		if _, err := os.Stat(root.abs); err == nil {
+			return w.attach(config, rootInd)
+		}
+
+		ancestor := nearestExistingDir(root.abs)
+		if config.pending[root.abs] == ancestor {
+			return nil
+		}
+
+		err := w.watcher.Add(ancestor)
+		if err != nil {
+			return fmt.Errorf("could not watch '%s' while waiting for '%s': %s", ancestor, root.abs, err.Error())
+		}
+
+		previous, wasPending := config.pending[root.abs]
+		config.pending[root.abs] = ancestor
+
+		if wasPending {
+			w.unwatch(previous)
+		} else {
+			fmt.Printf("Warning: '%s' does not exist, waiting for it to be created\n", root.abs)
+		}
+	}
+}
+
+// attach starts watching a root that was waiting to be created.
+func (w *PathWatcher) attach(config *pathConfig, rootInd int) error {
+	root := &config.roots[rootInd]
+
+	if info, err := os.Stat(root.abs); err == nil {
+		root.isDir = info.IsDir()
+	}
+
+	if resolved, err := filepath.EvalSymlinks(root.abs); err == nil {
+		root.resolved = resolved
+	}
+
+	ancestor, wasPending := config.pending[root.abs]
+	delete(config.pending, root.abs)
+
+	if wasPending {
+		fmt.Println("Found:", root.abs)
+		w.unwatch(ancestor)
+	}
+
+	return w.updatePathsAndWatchers(config, *root, root.abs)
+}
+
+// updatePending attaches roots that have been created and puts roots that
+// have disappeared back into waiting after an event for absFileLoc.
+func (w *PathWatcher) updatePending(config *pathConfig, absFileLoc string, op fsnotify.Op) error {
+	for rootInd, root := range config.roots {
+		if depthBelow(absFileLoc, root.abs) < 0 {
+			continue
+		}
+
+		if _, pending := config.pending[root.abs]; pending {
+			if op&(fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+				continue
+			}
+
+			err := w.waitFor(config, rootInd)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if _, err := os.Stat(root.abs); err == nil {
+			continue
+		}
+
+		w.removePaths(config, root.abs)
+
+		err := w.waitFor(config, rootInd)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *pathConfig) isPending(absFileLoc string) bool {
+	for _, ancestor := range c.pending {
+		if ancestor == absFileLoc {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unwatch removes absFileLoc from the underlying watcher unless a config is
+// still watching it or waiting on it.
+func (w *PathWatcher) unwatch(absFileLoc string) {
+	if w.isWatched(absFileLoc) {
+		return
+	}
+
+	_ = w.watcher.Remove(absFileLoc)
+}
+
+func nearestExistingDir(path string) string {
+	dir := filepath.Dir(path)
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+
+		if dir == filepath.Dir(dir) {
+			return dir
+		}
+
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,88 @@
+package watchers
+
+import (
+	"os"
+	"path/filepath"
+)
+
+const defaultMaxSnapshotSize = 64 * 1024
+
+// contentSnapshots keeps the content of small watched files so that triggers
+// can be told how a file changed, not just that it did.
+type contentSnapshots struct {
+	maxSize  int64
+	contents map[string][]byte
+}
+
+func newContentSnapshots(maxSize int64) *contentSnapshots {
+	if maxSize <= 0 {
+		maxSize = defaultMaxSnapshotSize
+	}
+
+	return &contentSnapshots{
+		maxSize:  maxSize,
+		contents: make(map[string][]byte),
+	}
+}
+
+func (s *contentSnapshots) seed(path string) {
+	if content, ok := s.read(path); ok {
+		s.contents[path] = content
+	}
+}
+
+func (s *contentSnapshots) forget(path string) {
+	delete(s.contents, path)
+}
+
+// update records the current content of path, returning the content it had
+// when it was last seen and whether there was any.
+func (s *contentSnapshots) update(prevPath, path string) (prev []byte, hadPrev bool, current []byte, hasCurrent bool) {
+	prev, hadPrev = s.contents[prevPath]
+	delete(s.contents, prevPath)
+
+	current, hasCurrent = s.read(path)
+	if hasCurrent {
+		s.contents[path] = current
+	}
+
+	return prev, hadPrev, current, hasCurrent
+}
+
+func (s *contentSnapshots) read(path string) ([]byte, bool) {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > s.maxSize {
+		return nil, false
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	return content, true
+}
+
+// writePrevious saves the previous content of path to a temporary file that
+// keeps the same extension, so tools that care about file types still work.
+func writePrevious(path string, content []byte) (string, error) {
+	f, err := os.CreateTemp("", "watchtower-prev-*"+filepath.Ext(path))
+	if err != nil {
+		return "", err
+	}
+
+	_, err = f.Write(content)
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	err = f.Close()
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
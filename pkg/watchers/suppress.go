@@ -0,0 +1,166 @@
+package watchers
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/iplay88keys/watchtower/pkg/runners"
+)
+
+// outputSettleTime is the least time changes to a trigger's outputs are
+// ignored for after it finishes, as their events can arrive after the
+// command that caused them has exited.
+const outputSettleTime = 250 * time.Millisecond
+
+// suppression ignores the changes a trigger causes itself, while it runs and
+// for a quiet period after it finishes.
+type suppression struct {
+	watch    string
+	outputs  []*regexp.Regexp
+	until    time.Time
+	running  bool
+	producer Producer
+}
+
+// Producer is something other than a trigger, such as a process, that writes
+// to watched paths.
+type Producer interface {
+	// Active returns whether it is running and, if not, when it stopped.
+	Active() (bool, time.Time)
+}
+
+// IgnoreOutputs ignores changes to the files matching the outputs globs while
+// the producer is active and shortly after it stops.
+func (w *PathWatcher) IgnoreOutputs(name string, outputs []string, producer Producer) error {
+	var compiled []*regexp.Regexp
+	for _, output := range outputs {
+		re, err := compileGlob(output)
+		if err != nil {
+			return fmt.Errorf("output '%s' of '%s' is not a valid glob: %s", output, name, err.Error())
+		}
+
+		compiled = append(compiled, re)
+	}
+
+	if len(compiled) == 0 {
+		return nil
+	}
+
+	w.suppressMu.Lock()
+	w.suppressions = append(w.suppressions, &suppression{
+		watch:    name,
+		outputs:  compiled,
+		producer: producer,
+	})
+	w.suppressMu.Unlock()
+
+	return nil
+}
+
+// active reports whether the suppression still ignores changes at now.
+func (s *suppression) active(now time.Time) bool {
+	if s.producer != nil {
+		running, stopped := s.producer.Active()
+
+		return running || now.Before(stopped.Add(outputSettleTime))
+	}
+
+	return s.running || now.Before(s.until)
+}
+
+func compileOutputs(runnerConfigs []*runners.Config) (map[*runners.Config][]*regexp.Regexp, error) {
+	outputs := make(map[*runners.Config][]*regexp.Regexp)
+	for _, runnerConfig := range runnerConfigs {
+		for _, output := range runnerConfig.Outputs {
+			re, err := compileGlob(output)
+			if err != nil {
+				return nil, fmt.Errorf("output '%s' is not a valid glob: %s", output, err.Error())
+			}
+
+			outputs[runnerConfig] = append(outputs[runnerConfig], re)
+		}
+	}
+
+	return outputs, nil
+}
+
+// suppress starts ignoring the changes a runner is about to cause, returning
+// nil if it has not declared any outputs or quiet period.
+func (w *PathWatcher) suppress(config pathConfig, runnerConfig *runners.Config) *suppression {
+	outputs := config.outputs[runnerConfig]
+	if len(outputs) == 0 && runnerConfig.QuietPeriod == 0 {
+		return nil
+	}
+
+	s := &suppression{
+		watch:   config.name,
+		outputs: outputs,
+		running: true,
+	}
+
+	w.suppressMu.Lock()
+	w.suppressions = append(w.suppressions, s)
+	w.suppressMu.Unlock()
+
+	return s
+}
+
+// release keeps ignoring a runner's changes for its quiet period once it has
+// finished.
+func (w *PathWatcher) release(s *suppression, runnerConfig *runners.Config) {
+	if s == nil {
+		return
+	}
+
+	quiet := time.Duration(runnerConfig.QuietPeriod)
+	if len(s.outputs) > 0 && quiet < outputSettleTime {
+		quiet = outputSettleTime
+	}
+
+	w.suppressMu.Lock()
+	s.running = false
+	s.until = time.Now().Add(quiet)
+	w.suppressMu.Unlock()
+}
+
+// selfTriggered reports whether a change to fileLoc for a config was caused
+// by one of watchtower's own triggers or processes. Changes to declared outputs are
+// ignored by every watch, while a quiet period without outputs only covers
+// the watch that ran the trigger.
+func (w *PathWatcher) selfTriggered(config *pathConfig, fileLoc string) bool {
+	w.suppressMu.Lock()
+	defer w.suppressMu.Unlock()
+
+	now := time.Now()
+
+	kept := w.suppressions[:0]
+	for _, s := range w.suppressions {
+		if s.producer != nil || s.active(now) {
+			kept = append(kept, s)
+		}
+	}
+	w.suppressions = kept
+
+	for _, s := range w.suppressions {
+		if !s.active(now) {
+			continue
+		}
+
+		if len(s.outputs) == 0 {
+			if s.watch == config.name {
+				return true
+			}
+
+			continue
+		}
+
+		for _, output := range s.outputs {
+			if output.MatchString(fileLoc) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
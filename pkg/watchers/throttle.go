@@ -0,0 +1,235 @@
+package watchers
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/iplay88keys/watchtower/pkg/runners"
+)
+
+// throttle keeps the runs of a watch within its options, holding the changes
+// that arrive before it may run again.
+type throttle struct {
+	minInterval    time.Duration
+	maxRunsPerHour int
+
+	mu    sync.Mutex
+	runs  []time.Time
+	held  *runners.Change
+	count int
+}
+
+func newThrottle(opts Options) *throttle {
+	if opts.MinInterval <= 0 && opts.MaxRunsPerHour <= 0 {
+		return nil
+	}
+
+	return &throttle{
+		minInterval:    time.Duration(opts.MinInterval),
+		maxRunsPerHour: opts.MaxRunsPerHour,
+	}
+}
+
+// delay returns how long until the watch may run again and why, or zero if
+// it may run now.
+func (t *throttle) delay(now time.Time) (time.Duration, string) {
+	hourAgo := now.Add(-time.Hour)
+	for len(t.runs) > 0 && !t.runs[0].After(hourAgo) {
+		t.runs = t.runs[1:]
+	}
+
+	var wait time.Duration
+	var reason string
+
+	if t.minInterval > 0 && len(t.runs) > 0 {
+		if d := t.runs[len(t.runs)-1].Add(t.minInterval).Sub(now); d > 0 {
+			wait = d
+			reason = fmt.Sprintf("minInterval of %s", t.minInterval)
+		}
+	}
+
+	if t.maxRunsPerHour > 0 && len(t.runs) >= t.maxRunsPerHour {
+		if d := t.runs[len(t.runs)-t.maxRunsPerHour].Add(time.Hour).Sub(now); d > wait {
+			wait = d
+			reason = fmt.Sprintf("maxRunsPerHour of %d", t.maxRunsPerHour)
+		}
+	}
+
+	return wait, reason
+}
+
+func (t *throttle) record(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.runs = append(t.runs, now)
+}
+
+// hold merges a change into the held one, returning whether a change was
+// already being held.
+func (t *throttle) hold(change runners.Change) bool {
+	if t.held == nil {
+		t.held = &change
+		t.count = 1
+
+		return false
+	}
+
+	merged := mergeChanges(*t.held, change)
+	t.held = &merged
+	t.count++
+
+	return true
+}
+
+func (t *throttle) holding() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.held != nil
+}
+
+func (t *throttle) take() (runners.Change, int) {
+	change, count := *t.held, t.count
+	t.held = nil
+	t.count = 0
+
+	return change, count
+}
+
+// mergeChanges combines a held change with a later one. The previous content
+// is kept only while every change is to the same file, as it is the content
+// from before the first of them.
+func mergeChanges(held, next runners.Change) runners.Change {
+	names := held.Names
+	if len(names) == 0 {
+		names = []string{held.Name}
+	}
+
+	found := false
+	for _, name := range names {
+		if name == next.Name {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		names = append(names, next.Name)
+	}
+
+	prevPath := held.PrevPath
+	if prevPath == "" {
+		prevPath = next.PrevPath
+	} else if next.PrevPath != "" {
+		os.Remove(next.PrevPath)
+	}
+
+	if len(names) > 1 && prevPath != "" {
+		os.Remove(prevPath)
+		prevPath = ""
+	}
+
+	return runners.Change{
+		Name:     next.Name,
+		Names:    names,
+		OldName:  next.OldName,
+		Op:       next.Op,
+		Diff:     held.Diff + next.Diff,
+		PrevPath: prevPath,
+	}
+}
+
+// trigger runs the triggers of a config for a change, or holds the change
+// if the config's options don't allow it to run yet or it is still running.
+func (w *PathWatcher) trigger(configInd int, change runners.Change) {
+	config := &w.paths[configInd]
+	if config.throttle == nil {
+		w.start(configInd, change)
+		return
+	}
+
+	t := config.throttle
+	t.mu.Lock()
+
+	now := time.Now()
+	if t.held != nil {
+		t.hold(change)
+		fmt.Printf("Holding change for '%s': %s, %s (%d changes held)\n", config.name, change.Name, change.Op, t.count)
+		t.mu.Unlock()
+
+		return
+	}
+
+	if config.Concurrency != ConcurrencyRestart && w.isRunning(configInd) {
+		t.hold(change)
+		t.mu.Unlock()
+
+		fmt.Printf("Holding change for '%s' until its triggers finish: %s, %s\n", config.name, change.Name, change.Op)
+
+		return
+	}
+
+	wait, reason := t.delay(now)
+	if wait > 0 {
+		t.hold(change)
+		t.mu.Unlock()
+
+		fmt.Printf("Deferring run for '%s' until %s due to %s: %s, %s\n",
+			config.name, now.Add(wait).Format("15:04:05"), reason, change.Name, change.Op)
+		w.schedule(configInd, wait)
+
+		return
+	}
+
+	t.runs = append(t.runs, now)
+	t.mu.Unlock()
+
+	w.start(configInd, change)
+}
+
+// releaseHeld runs the changes held for a config once it is allowed to run,
+// or defers them again if it still isn't. Changes held while the config is
+// running are released again when it finishes.
+func (w *PathWatcher) releaseHeld(configInd int) {
+	config := &w.paths[configInd]
+	t := config.throttle
+	t.mu.Lock()
+
+	if t.held == nil || (config.Concurrency != ConcurrencyRestart && w.isRunning(configInd)) {
+		t.mu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	wait, reason := t.delay(now)
+	if wait > 0 {
+		t.mu.Unlock()
+
+		fmt.Printf("Deferring run for '%s' until %s due to %s\n", config.name, now.Add(wait).Format("15:04:05"), reason)
+		w.schedule(configInd, wait)
+
+		return
+	}
+
+	change, count := t.take()
+	t.runs = append(t.runs, now)
+	t.mu.Unlock()
+
+	fmt.Printf("Running %d held change(s) for '%s'\n", count, config.name)
+
+	w.start(configInd, change)
+}
+
+// schedule hands a config back to the event loop after wait so that its
+// held changes can run.
+func (w *PathWatcher) schedule(configInd int, wait time.Duration) {
+	time.AfterFunc(wait, func() {
+		select {
+		case w.released <- configInd:
+		case <-w.done:
+		}
+	})
+}
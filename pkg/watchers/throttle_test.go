@@ -0,0 +1,133 @@
+package watchers
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/iplay88keys/watchtower/pkg/runners"
+)
+
+var _ = Describe("throttle", func() {
+	var start time.Time
+
+	BeforeEach(func() {
+		start = time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	})
+
+	It("isn't needed without any limits", func() {
+		Expect(newThrottle(Options{})).To(BeNil())
+	})
+
+	It("waits out the rest of the min interval since the last run", func() {
+		t := newThrottle(Options{MinInterval: runners.Duration(5 * time.Minute)})
+
+		wait, _ := t.delay(start)
+		Expect(wait).To(BeZero())
+
+		t.record(start)
+
+		wait, reason := t.delay(start.Add(2 * time.Minute))
+		Expect(wait).To(Equal(3 * time.Minute))
+		Expect(reason).To(Equal("minInterval of 5m0s"))
+
+		wait, _ = t.delay(start.Add(5 * time.Minute))
+		Expect(wait).To(BeZero())
+	})
+
+	It("waits until the oldest run in the last hour has expired", func() {
+		t := newThrottle(Options{MaxRunsPerHour: 2})
+
+		t.record(start)
+		t.record(start.Add(10 * time.Minute))
+
+		wait, reason := t.delay(start.Add(20 * time.Minute))
+		Expect(wait).To(Equal(40 * time.Minute))
+		Expect(reason).To(Equal("maxRunsPerHour of 2"))
+
+		wait, _ = t.delay(start.Add(time.Hour))
+		Expect(wait).To(BeZero())
+		Expect(t.runs).To(HaveLen(1))
+	})
+
+	It("reports the longest of the limits", func() {
+		t := newThrottle(Options{
+			MinInterval:    runners.Duration(time.Minute),
+			MaxRunsPerHour: 1,
+		})
+
+		t.record(start)
+
+		wait, reason := t.delay(start.Add(30 * time.Second))
+		Expect(wait).To(Equal(time.Hour - 30*time.Second))
+		Expect(reason).To(Equal("maxRunsPerHour of 1"))
+	})
+
+	It("merges held changes until they are taken", func() {
+		t := newThrottle(Options{MaxRunsPerHour: 1})
+
+		Expect(t.hold(runners.Change{Name: "a.go", Op: "WRITE"})).To(BeFalse())
+		Expect(t.hold(runners.Change{Name: "b.go", Op: "CREATE"})).To(BeTrue())
+		Expect(t.hold(runners.Change{Name: "a.go", Op: "WRITE"})).To(BeTrue())
+
+		change, count := t.take()
+		Expect(count).To(Equal(3))
+		Expect(change).To(Equal(runners.Change{
+			Name:  "a.go",
+			Names: []string{"a.go", "b.go"},
+			Op:    "WRITE",
+		}))
+		Expect(t.held).To(BeNil())
+	})
+})
+
+var _ = Describe("mergeChanges", func() {
+	var tmpDir string
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "*")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	prevFile := func(name string) string {
+		f, err := ioutil.TempFile(tmpDir, name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(f.Close()).To(Succeed())
+
+		return f.Name()
+	}
+
+	It("keeps the earliest previous content of a file changed repeatedly", func() {
+		first, second := prevFile("first"), prevFile("second")
+
+		merged := mergeChanges(
+			runners.Change{Name: "a.go", Diff: "first\n", PrevPath: first},
+			runners.Change{Name: "a.go", Diff: "second\n", PrevPath: second},
+		)
+
+		Expect(merged.PrevPath).To(Equal(first))
+		Expect(merged.Diff).To(Equal("first\nsecond\n"))
+		Expect(second).ToNot(BeAnExistingFile())
+	})
+
+	It("drops the previous content once different files have changed", func() {
+		first, second := prevFile("first"), prevFile("second")
+
+		merged := mergeChanges(
+			runners.Change{Name: "a.go", PrevPath: first},
+			runners.Change{Name: "b.go", PrevPath: second},
+		)
+
+		Expect(merged.PrevPath).To(BeEmpty())
+		Expect(first).ToNot(BeAnExistingFile())
+		Expect(second).ToNot(BeAnExistingFile())
+	})
+})
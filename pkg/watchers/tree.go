@@ -0,0 +1,165 @@
+package watchers
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// pathTree is a prefix tree of absolute paths keyed by path component.
+// Lookups and updates cost O(depth) rather than O(number of paths), which
+// keeps event handling cheap for very large trees.
+type pathTree struct {
+	root pathNode
+}
+
+type pathNode struct {
+	children map[string]*pathNode
+	present  bool
+}
+
+func newPathTree() *pathTree {
+	return &pathTree{}
+}
+
+// splitPath splits an absolute path into its components. The volume name,
+// such as "C:" on Windows, is kept as the first component so that paths on
+// different volumes don't share a node.
+func splitPath(path string) []string {
+	path = filepath.Clean(path)
+
+	var components []string
+
+	volume := filepath.VolumeName(path)
+	if volume != "" {
+		components = append(components, volume)
+	}
+
+	rest := strings.TrimPrefix(path[len(volume):], string(filepath.Separator))
+	if rest == "" {
+		return components
+	}
+
+	return append(components, strings.Split(rest, string(filepath.Separator))...)
+}
+
+// joinPath joins components split by splitPath back into an absolute path.
+func joinPath(components []string) string {
+	var volume string
+	if len(components) > 0 && components[0] == filepath.VolumeName(components[0]) {
+		volume = components[0]
+		components = components[1:]
+	}
+
+	return volume + string(filepath.Separator) + filepath.Join(components...)
+}
+
+func (t *pathTree) insert(path string) bool {
+	node := &t.root
+	for _, component := range splitPath(path) {
+		if node.children == nil {
+			node.children = make(map[string]*pathNode)
+		}
+
+		child, ok := node.children[component]
+		if !ok {
+			child = &pathNode{}
+			node.children[component] = child
+		}
+
+		node = child
+	}
+
+	if node.present {
+		return false
+	}
+
+	node.present = true
+
+	return true
+}
+
+func (t *pathTree) find(path string) *pathNode {
+	node := &t.root
+	for _, component := range splitPath(path) {
+		child, ok := node.children[component]
+		if !ok {
+			return nil
+		}
+
+		node = child
+	}
+
+	return node
+}
+
+func (t *pathTree) contains(path string) bool {
+	node := t.find(path)
+
+	return node != nil && node.present
+}
+
+// hasAncestor reports whether any strict ancestor of path is in the tree.
+func (t *pathTree) hasAncestor(path string) bool {
+	node := &t.root
+	for _, component := range splitPath(path) {
+		if node.present {
+			return true
+		}
+
+		child, ok := node.children[component]
+		if !ok {
+			return false
+		}
+
+		node = child
+	}
+
+	return false
+}
+
+// remove deletes path and everything beneath it, returning the removed paths
+// in sorted order.
+func (t *pathTree) remove(path string) []string {
+	components := splitPath(path)
+
+	parent := &t.root
+	for i, component := range components {
+		child, ok := parent.children[component]
+		if !ok {
+			return nil
+		}
+
+		if i == len(components)-1 {
+			var removed []string
+			collect(child, components, &removed)
+			delete(parent.children, component)
+			sort.Strings(removed)
+
+			return removed
+		}
+
+		parent = child
+	}
+
+	var removed []string
+	collect(&t.root, nil, &removed)
+	t.root = pathNode{}
+	sort.Strings(removed)
+
+	return removed
+}
+
+func collect(node *pathNode, components []string, paths *[]string) {
+	if node.present {
+		*paths = append(*paths, joinPath(components))
+	}
+
+	for component, child := range node.children {
+		next := make([]string, len(components)+1)
+		copy(next, components)
+		next[len(components)] = component
+
+		collect(child, next, paths)
+	}
+}
@@ -0,0 +1,139 @@
+package watchers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("pathTree", func() {
+	var tree *pathTree
+
+	BeforeEach(func() {
+		tree = newPathTree()
+		tree.insert("/src")
+		tree.insert("/src/api")
+		tree.insert("/src/api/main.go")
+		tree.insert("/src/web/index.js")
+	})
+
+	It("tracks which paths have been inserted", func() {
+		Expect(tree.contains("/src/api")).To(BeTrue())
+		Expect(tree.contains("/src/web/index.js")).To(BeTrue())
+		Expect(tree.contains("/src/web")).To(BeFalse())
+		Expect(tree.contains("/src/ap")).To(BeFalse())
+	})
+
+	It("only reports newly inserted paths", func() {
+		Expect(tree.insert("/src/api")).To(BeFalse())
+		Expect(tree.insert("/src/web")).To(BeTrue())
+		Expect(tree.contains("/src/web")).To(BeTrue())
+	})
+
+	It("finds ancestors by path component", func() {
+		Expect(tree.hasAncestor("/src/api/handler.go")).To(BeTrue())
+		Expect(tree.hasAncestor("/src/api-gateway/main.go")).To(BeTrue())
+		Expect(tree.hasAncestor("/src")).To(BeFalse())
+		Expect(tree.hasAncestor("/other/src/api")).To(BeFalse())
+	})
+
+	It("removes a path and everything beneath it", func() {
+		Expect(tree.remove("/src/api")).To(Equal([]string{"/src/api", "/src/api/main.go"}))
+		Expect(tree.contains("/src/api/main.go")).To(BeFalse())
+		Expect(tree.contains("/src")).To(BeTrue())
+		Expect(tree.contains("/src/web/index.js")).To(BeTrue())
+
+		Expect(tree.remove("/missing")).To(BeEmpty())
+	})
+
+	It("keeps the volume name when splitting and joining paths", func() {
+		root := filepath.VolumeName(os.TempDir()) + string(filepath.Separator)
+		path := filepath.Join(root, "src", "api")
+
+		Expect(joinPath(splitPath(path))).To(Equal(path))
+		Expect(joinPath(splitPath(root))).To(Equal(root))
+	})
+})
+
+// syntheticTree builds a tree shaped like a large monorepo: 150 packages of
+// 10 directories holding 100 files each.
+func syntheticTree() (*pathTree, []string) {
+	tree := newPathTree()
+
+	var paths []string
+	for pkg := 0; pkg < 150; pkg++ {
+		for dir := 0; dir < 10; dir++ {
+			base := filepath.Join("/repo", fmt.Sprintf("pkg%d", pkg), fmt.Sprintf("dir%d", dir))
+			tree.insert(base)
+
+			for file := 0; file < 100; file++ {
+				path := filepath.Join(base, fmt.Sprintf("file%d.go", file))
+				tree.insert(path)
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	return tree, paths
+}
+
+func BenchmarkPathTreeInsert(b *testing.B) {
+	_, paths := syntheticTree()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree := newPathTree()
+		for _, path := range paths {
+			tree.insert(path)
+		}
+	}
+}
+
+func BenchmarkPathTreeContains(b *testing.B) {
+	tree, paths := syntheticTree()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.contains(paths[i%len(paths)])
+	}
+}
+
+func BenchmarkPathConfigLocate(b *testing.B) {
+	_, paths := syntheticTree()
+
+	var roots []watchRoot
+	for pkg := 0; pkg < 150; pkg++ {
+		abs := filepath.Join("/repo", fmt.Sprintf("pkg%d", pkg))
+		roots = append(roots, watchRoot{path: abs, abs: abs, resolved: abs, isDir: true, maxDepth: -1})
+	}
+
+	config := pathConfig{roots: roots}
+	resolve := func() string { return "" }
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fileLoc, root, ok := config.locate(paths[i%len(paths)], resolve)
+		if ok {
+			root.contains(fileLoc)
+		}
+	}
+}
+
+func BenchmarkPathTreeRemoveSubtree(b *testing.B) {
+	tree, _ := syntheticTree()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		removed := tree.remove("/repo/pkg75")
+
+		b.StopTimer()
+		for _, path := range removed {
+			tree.insert(path)
+		}
+		b.StartTimer()
+	}
+}
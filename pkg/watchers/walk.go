@@ -0,0 +1,133 @@
+package watchers
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// walkOptions controls how walkPath treats symlinks.
+type walkOptions struct {
+	// followStart follows start if it is a symlink, so a root that links to
+	// a directory is walked like the directory itself.
+	followStart bool
+
+	// followSymlinks descends into symlinks to directories beneath start.
+	// Paths are reported under the link, not the directory it resolves to.
+	followSymlinks bool
+
+	// ancestors are the resolved directories above start, used to detect
+	// symlink cycles when only part of a tree is walked.
+	ancestors []string
+}
+
+// walkPath walks the tree rooted at start, calling fn for start and every
+// path beneath it in lexical order. It behaves like filepath.Walk apart from
+// the symlink handling described by opts.
+func walkPath(start string, opts walkOptions, fn filepath.WalkFunc) error {
+	stat := os.Lstat
+	if opts.followStart || opts.followSymlinks {
+		stat = os.Stat
+	}
+
+	visiting := make(map[string]bool)
+	for _, ancestor := range opts.ancestors {
+		visiting[ancestor] = true
+	}
+
+	info, err := stat(start)
+	if err != nil {
+		err = fn(start, nil, err)
+	} else {
+		err = walk(start, info, opts.followSymlinks, visiting, fn)
+	}
+
+	if err == filepath.SkipDir {
+		return nil
+	}
+
+	return err
+}
+
+func walk(path string, info fs.FileInfo, followSymlinks bool, visiting map[string]bool, fn filepath.WalkFunc) error {
+	if followSymlinks && info.Mode()&fs.ModeSymlink != 0 {
+		targetInfo, err := os.Stat(path)
+		if err == nil && targetInfo.IsDir() {
+			info = targetInfo
+		}
+	}
+
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+
+	if followSymlinks {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			if visiting[resolved] {
+				fmt.Printf("Skipping symlink cycle: '%s' -> '%s'\n", path, resolved)
+				return nil
+			}
+
+			visiting[resolved] = true
+			defer delete(visiting, resolved)
+		}
+	}
+
+	err := fn(path, info, nil)
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return fn(path, info, err)
+	}
+
+	for _, entry := range entries {
+		childPath := filepath.Join(path, entry.Name())
+
+		childInfo, err := entry.Info()
+		if err != nil {
+			err = fn(childPath, nil, err)
+			if err != nil && err != filepath.SkipDir {
+				return err
+			}
+
+			continue
+		}
+
+		err = walk(childPath, childInfo, followSymlinks, visiting, fn)
+		if err != nil {
+			if err == filepath.SkipDir {
+				if childInfo.IsDir() || childInfo.Mode()&fs.ModeSymlink != 0 {
+					continue
+				}
+
+				return nil
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolvedAncestors returns the resolved directories from root down to, but
+// not including, path.
+func resolvedAncestors(root, path string) []string {
+	var ancestors []string
+	for dir := filepath.Dir(path); depthBelow(root, dir) >= 0; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			ancestors = append(ancestors, resolved)
+		}
+
+		if dir == root || dir == filepath.Dir(dir) {
+			break
+		}
+	}
+
+	return ancestors
+}
@@ -0,0 +1,88 @@
+package watchers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("walkPath", func() {
+	var (
+		tmpDir    string
+		sharedDir string
+		treeDir   string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "*")
+		Expect(err).ToNot(HaveOccurred())
+
+		tmpDir, err = filepath.EvalSymlinks(tmpDir)
+		Expect(err).ToNot(HaveOccurred())
+
+		sharedDir = filepath.Join(tmpDir, "shared")
+		treeDir = filepath.Join(tmpDir, "tree")
+
+		Expect(os.MkdirAll(filepath.Join(sharedDir, "proto"), os.ModePerm)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(sharedDir, "proto", "api.proto"), nil, os.ModePerm)).To(Succeed())
+		Expect(os.MkdirAll(treeDir, os.ModePerm)).To(Succeed())
+		Expect(os.Symlink(sharedDir, filepath.Join(treeDir, "shared"))).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	walked := func(start string, opts walkOptions) []string {
+		var paths []string
+		err := walkPath(start, opts, func(path string, info os.FileInfo, err error) error {
+			paths = append(paths, path)
+			return err
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		return paths
+	}
+
+	It("reports paths beneath followed symlinks under the link", func() {
+		Expect(walked(treeDir, walkOptions{followSymlinks: true})).To(Equal([]string{
+			treeDir,
+			filepath.Join(treeDir, "shared"),
+			filepath.Join(treeDir, "shared", "proto"),
+			filepath.Join(treeDir, "shared", "proto", "api.proto"),
+		}))
+	})
+
+	It("skips symlinks that lead back to an ancestor", func() {
+		Expect(os.Symlink(treeDir, filepath.Join(sharedDir, "loop"))).To(Succeed())
+
+		paths := walked(treeDir, walkOptions{followSymlinks: true})
+		Expect(paths).To(ContainElement(filepath.Join(treeDir, "shared", "proto", "api.proto")))
+		Expect(paths).ToNot(ContainElement(filepath.Join(treeDir, "shared", "loop")))
+	})
+
+	It("detects cycles through the ancestors of a partial walk", func() {
+		Expect(os.Symlink(treeDir, filepath.Join(sharedDir, "loop"))).To(Succeed())
+
+		start := filepath.Join(treeDir, "shared")
+		paths := walked(start, walkOptions{
+			followSymlinks: true,
+			ancestors:      resolvedAncestors(treeDir, start),
+		})
+
+		Expect(paths).To(ContainElement(filepath.Join(start, "proto")))
+		Expect(paths).ToNot(ContainElement(filepath.Join(start, "loop")))
+	})
+
+	It("follows the same target through separate links", func() {
+		Expect(os.Symlink(sharedDir, filepath.Join(treeDir, "again"))).To(Succeed())
+
+		paths := walked(treeDir, walkOptions{followSymlinks: true})
+		Expect(paths).To(ContainElement(filepath.Join(treeDir, "again", "proto", "api.proto")))
+		Expect(paths).To(ContainElement(filepath.Join(treeDir, "shared", "proto", "api.proto")))
+	})
+})